@@ -0,0 +1,56 @@
+package resourceutil
+
+import (
+	"fmt"
+	"log/slog"
+	"time"
+)
+
+// SystemActivity represents the scheduler activity of the system over an interval.
+// Fields:
+//   - ContextSwitchesPerSec (float64): The number of context switches per second.
+//   - ForksPerSec (float64): The number of processes and threads created per second.
+//   - ProcsRunning (int): The number of runnable threads at the end of the interval.
+//   - ProcsBlocked (int): The number of threads blocked waiting for I/O at the end of the interval.
+type SystemActivity struct {
+	ContextSwitchesPerSec float64
+	ForksPerSec           float64
+	ProcsRunning          int
+	ProcsBlocked          int
+}
+
+// GetSystemActivity measures the context switch and fork rates from /proc/stat over the given interval.
+// This call blocks for the duration of the interval.
+func GetSystemActivity(interval time.Duration) (SystemActivity, error) {
+	if interval <= 0 {
+		return SystemActivity{}, fmt.Errorf("interval must be positive, got %s", interval)
+	}
+
+	before, err := readProcStat()
+	if err != nil {
+		return SystemActivity{}, err
+	}
+	start := time.Now()
+
+	time.Sleep(interval)
+
+	after, err := readProcStat()
+	if err != nil {
+		return SystemActivity{}, err
+	}
+	seconds := time.Since(start).Seconds()
+
+	if after.contextSwitches < before.contextSwitches || after.forks < before.forks {
+		return SystemActivity{}, fmt.Errorf("activity counters in /proc/stat went backwards")
+	}
+
+	activity := SystemActivity{
+		ContextSwitchesPerSec: float64(after.contextSwitches-before.contextSwitches) / seconds,
+		ForksPerSec:           float64(after.forks-before.forks) / seconds,
+		ProcsRunning:          after.procsRunning,
+		ProcsBlocked:          after.procsBlocked,
+	}
+	slog.Debug("Got system activity", slog.Any("system_activity", activity))
+
+	return activity, nil
+}
@@ -1,12 +1,20 @@
 package resourceutil
 
 import (
+	"errors"
 	"fmt"
+	"log/slog"
+	"math"
 	"os"
+	"path/filepath"
 	"strconv"
 	"strings"
+	"time"
 )
 
+// powerSupplyDir is the sysfs directory where the kernel exposes power supplies.
+const powerSupplyDir = "/sys/class/power_supply"
+
 // intFromFile reads a file at the specified path and attempts to parse its contents as an integer.
 func intFromFile(path string) (int, error) {
 	// Read the data
@@ -24,6 +32,16 @@ func intFromFile(path string) (int, error) {
 	return dataInt, nil
 }
 
+// stringFromFile reads a file at the specified path and returns its contents with surrounding whitespace removed.
+func stringFromFile(path string) (string, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return "", fmt.Errorf("failed to read string at path %s, error: %w", path, err)
+	}
+
+	return strings.TrimSpace(string(data)), nil
+}
+
 // GetBatterySOC retrieves the State of Charge (SOC) of the battery as a percentage.
 func GetBatterySOC(batteryName string) (int, error) {
 	if batteryName == "" {
@@ -39,32 +57,548 @@ func GetBatterySOC(batteryName string) (int, error) {
 	return capacity, nil
 }
 
+// sohOptions holds the settings used by GetBatterySOH.
+type sohOptions struct {
+	raw bool
+}
+
+// SOHOption configures GetBatterySOH.
+type SOHOption func(*sohOptions)
+
+// WithRawSOH disables clamping so GetBatterySOH returns values above 100 as reported, useful for diagnostics.
+func WithRawSOH() SOHOption {
+	return func(o *sohOptions) {
+		o.raw = true
+	}
+}
+
 // GetBatterySOH retrieves the State of Health (SOH) of the battery as a percentage.
 //
 // SOH is calculated as the ratio of the battery's current maximum energy capacity
-// as a percentage of its original design capacity.
-func GetBatterySOH(batteryName string) (int, error) {
+// as a percentage of its original design capacity. Batteries that report charge (charge_full and
+// charge_full_design) instead of energy, common on ARM laptops and phones, are supported as well.
+//
+// New or freshly calibrated batteries may report a maximum capacity slightly above the design
+// capacity. The result is therefore clamped to 100 unless WithRawSOH is passed.
+func GetBatterySOH(batteryName string, opts ...SOHOption) (int, error) {
+	var options sohOptions
+	for _, opt := range opts {
+		opt(&options)
+	}
+
 	if batteryName == "" {
 		return 0, fmt.Errorf("battery name cannot be empty")
 	}
 
-	energyFullPath := fmt.Sprintf("/sys/class/power_supply/%s/energy_full", batteryName)
-	energyFullDesignPath := fmt.Sprintf("/sys/class/power_supply/%s/energy_full_design", batteryName)
+	full, fullDesign, err := readBatteryDesignCapacity(batteryName)
+	if err != nil {
+		return 0, err
+	}
+
+	if fullDesign == 0 {
+		return 0, fmt.Errorf("design capacity is zero, cannot calculate SOH for battery %s", batteryName)
+	}
+
+	stateOfHealth := 100 * full / fullDesign
+	if !options.raw {
+		stateOfHealth = min(stateOfHealth, 100)
+	}
+
+	return stateOfHealth, nil
+}
 
-	energyFull, err := intFromFile(energyFullPath)
+// readBatteryDesignCapacity reads the current and design full capacity of a battery from energy_full and
+// energy_full_design, falling back to charge_full and charge_full_design. Both values are in the same unit.
+func readBatteryDesignCapacity(batteryName string) (full, fullDesign int, err error) {
+	dir := filepath.Join(powerSupplyDir, batteryName)
+
+	prefix := "energy"
+	full, err = intFromFile(filepath.Join(dir, "energy_full"))
+	if errors.Is(err, os.ErrNotExist) {
+		prefix = "charge"
+		full, err = intFromFile(filepath.Join(dir, "charge_full"))
+	}
 	if err != nil {
-		return 0, fmt.Errorf("failed to retrieve energy_full for battery %s: %w", batteryName, err)
+		return 0, 0, fmt.Errorf("failed to retrieve full capacity for battery %s: %w", batteryName, err)
 	}
 
-	energyFullDesign, err := intFromFile(energyFullDesignPath)
+	fullDesign, err = intFromFile(filepath.Join(dir, prefix+"_full_design"))
 	if err != nil {
-		return 0, fmt.Errorf("failed to retrieve energy_full_design for battery %s: %w", batteryName, err)
+		return 0, 0, fmt.Errorf("failed to retrieve %s_full_design for battery %s: %w", prefix, batteryName, err)
+	}
+
+	return full, fullDesign, nil
+}
+
+// BatteryStatus is the charging status of a battery as reported in its sysfs status attribute.
+type BatteryStatus string
+
+const (
+	BatteryCharging    BatteryStatus = "Charging"
+	BatteryDischarging BatteryStatus = "Discharging"
+	BatteryFull        BatteryStatus = "Full"
+	// BatteryNotCharging is reported when external power is connected but the battery is not charging,
+	// e.g. because a charge threshold has been reached.
+	BatteryNotCharging BatteryStatus = "Not charging"
+	BatteryUnknown     BatteryStatus = "Unknown"
+)
+
+// GetBatteryStatus retrieves whether the battery is charging, discharging, full or not charging.
+// Statuses not listed above are passed through as reported by the driver.
+func GetBatteryStatus(batteryName string) (BatteryStatus, error) {
+	if batteryName == "" {
+		return "", fmt.Errorf("battery name cannot be empty")
 	}
 
-	if energyFullDesign == 0 {
-		return 0, fmt.Errorf("energy_full_design is zero, cannot calculate SOH for battery %s", batteryName)
+	status, err := stringFromFile(filepath.Join(powerSupplyDir, batteryName, "status"))
+	if err != nil {
+		return "", fmt.Errorf("failed to get battery status for %s: %w", batteryName, err)
 	}
 
-	stateOfHealth := 100 * energyFull / energyFullDesign
-	return stateOfHealth, nil
+	return BatteryStatus(status), nil
+}
+
+// BatteryCapacityLevel is the coarse state of charge of a battery as reported in its sysfs capacity_level attribute.
+// Some drivers report only this instead of a percentage.
+type BatteryCapacityLevel string
+
+const (
+	BatteryLevelUnknown  BatteryCapacityLevel = "Unknown"
+	BatteryLevelCritical BatteryCapacityLevel = "Critical"
+	BatteryLevelLow      BatteryCapacityLevel = "Low"
+	BatteryLevelNormal   BatteryCapacityLevel = "Normal"
+	BatteryLevelHigh     BatteryCapacityLevel = "High"
+	BatteryLevelFull     BatteryCapacityLevel = "Full"
+)
+
+// GetBatteryCapacityLevel retrieves the coarse state of charge of the battery from capacity_level.
+// Levels not listed above are passed through as reported by the driver.
+func GetBatteryCapacityLevel(batteryName string) (BatteryCapacityLevel, error) {
+	if batteryName == "" {
+		return "", fmt.Errorf("battery name cannot be empty")
+	}
+
+	level, err := stringFromFile(filepath.Join(powerSupplyDir, batteryName, "capacity_level"))
+	if err != nil {
+		return "", fmt.Errorf("failed to get battery capacity level for %s: %w", batteryName, err)
+	}
+
+	return BatteryCapacityLevel(level), nil
+}
+
+// BatteryHealth is the health of a battery as reported in its sysfs health attribute.
+type BatteryHealth string
+
+const (
+	BatteryHealthUnknown             BatteryHealth = "Unknown"
+	BatteryHealthGood                BatteryHealth = "Good"
+	BatteryHealthOverheat            BatteryHealth = "Overheat"
+	BatteryHealthDead                BatteryHealth = "Dead"
+	BatteryHealthOverVoltage         BatteryHealth = "Over voltage"
+	BatteryHealthUnspecifiedFailure  BatteryHealth = "Unspecified failure"
+	BatteryHealthCold                BatteryHealth = "Cold"
+	BatteryHealthWatchdogTimerExpire BatteryHealth = "Watchdog timer expire"
+	BatteryHealthSafetyTimerExpire   BatteryHealth = "Safety timer expire"
+	BatteryHealthOverCurrent         BatteryHealth = "Over current"
+	BatteryHealthCalibrationRequired BatteryHealth = "Calibration required"
+	BatteryHealthWarm                BatteryHealth = "Warm"
+	BatteryHealthCool                BatteryHealth = "Cool"
+	BatteryHealthHot                 BatteryHealth = "Hot"
+	BatteryHealthNoBattery           BatteryHealth = "No battery"
+)
+
+// GetBatteryHealth retrieves the health of the battery from the health attribute, which is mostly exposed by the
+// charger drivers of phones and embedded boards. Values not listed above are passed through as reported by the driver.
+func GetBatteryHealth(batteryName string) (BatteryHealth, error) {
+	if batteryName == "" {
+		return "", fmt.Errorf("battery name cannot be empty")
+	}
+
+	health, err := stringFromFile(filepath.Join(powerSupplyDir, batteryName, "health"))
+	if err != nil {
+		return "", fmt.Errorf("failed to get battery health for %s: %w", batteryName, err)
+	}
+
+	return BatteryHealth(health), nil
+}
+
+// ACAdapter represents the state and ratings of an AC/mains power supply.
+// Fields:
+//   - Name (string): The sysfs name of the power supply, e.g. "AC" or "ADP1".
+//   - Online (bool): Whether external power is connected.
+//   - VoltageMaxV (float64): The maximum supported voltage in volts, 0 if not exposed.
+//   - CurrentMaxA (float64): The maximum supplied current or input current limit in amperes, 0 if not exposed.
+//   - RatedPowerW (float64): The rated power in watts, 0 if not exposed and not derivable.
+type ACAdapter struct {
+	Name        string
+	Online      bool
+	VoltageMaxV float64
+	CurrentMaxA float64
+	RatedPowerW float64
+}
+
+// ErrNoACAdapter is returned when no AC/mains power supply can be found.
+var ErrNoACAdapter = errors.New("no AC adapter found")
+
+// GetACAdapterInfo retrieves whether AC power is present along with any rated power information the adapter exposes.
+//
+// Many adapters only expose the online attribute, in which case the rating fields are left at zero.
+// The rated power is read from input_power_limit when present, otherwise it is derived from
+// voltage_max and current_max (or input_current_limit).
+func GetACAdapterInfo() (ACAdapter, error) {
+	name, err := findACAdapter()
+	if err != nil {
+		return ACAdapter{}, err
+	}
+
+	dir := filepath.Join(powerSupplyDir, name)
+	online, err := intFromFile(filepath.Join(dir, "online"))
+	if err != nil {
+		return ACAdapter{}, fmt.Errorf("failed to get online state for AC adapter %s: %w", name, err)
+	}
+
+	adapter := ACAdapter{
+		Name:   name,
+		Online: online == 1,
+	}
+
+	// The remaining attributes are optional and reported in micro units.
+	if voltage, err := intFromFile(filepath.Join(dir, "voltage_max")); err == nil {
+		adapter.VoltageMaxV = float64(voltage) / 1e6
+	}
+
+	if current, err := intFromFile(filepath.Join(dir, "current_max")); err == nil {
+		adapter.CurrentMaxA = float64(current) / 1e6
+	} else if current, err := intFromFile(filepath.Join(dir, "input_current_limit")); err == nil {
+		adapter.CurrentMaxA = float64(current) / 1e6
+	}
+
+	if power, err := intFromFile(filepath.Join(dir, "input_power_limit")); err == nil {
+		adapter.RatedPowerW = float64(power) / 1e6
+	} else {
+		adapter.RatedPowerW = adapter.VoltageMaxV * adapter.CurrentMaxA
+	}
+
+	return adapter, nil
+}
+
+// GetACOnline reports whether external power is connected from the online attribute of the Mains power supplies
+// and, for machines charged over USB-C, of the USB power supplies. Returns ErrNoACAdapter if there are none.
+func GetACOnline() (bool, error) {
+	supplies, err := ListPowerSupplies()
+	if err != nil {
+		return false, err
+	}
+
+	found := false
+	for _, supply := range supplies {
+		if (supply.Type != PowerSupplyMains && supply.Type != PowerSupplyUSB) || supply.Scope == "Device" {
+			continue
+		}
+		online, err := intFromFile(filepath.Join(powerSupplyDir, supply.Name, "online"))
+		if err != nil {
+			slog.Debug("Skipping power supply without online state", slog.String("name", supply.Name), slog.Any("error", err))
+			continue
+		}
+		found = true
+		if online == 1 {
+			return true, nil
+		}
+	}
+
+	if !found {
+		return false, ErrNoACAdapter
+	}
+
+	return false, nil
+}
+
+// findACAdapter returns the sysfs name of the first power supply of type Mains.
+func findACAdapter() (string, error) {
+	supplies, err := ListPowerSupplies()
+	if err != nil {
+		return "", err
+	}
+
+	for _, supply := range supplies {
+		if supply.Type == PowerSupplyMains {
+			return supply.Name, nil
+		}
+	}
+
+	return "", ErrNoACAdapter
+}
+
+// batteryLevel holds the remaining and full capacity of a battery with the rate it currently charges or
+// discharges at, either as energy in µWh and power in µW or, for drivers without energy attributes,
+// as charge in µAh and current in µA.
+type batteryLevel struct {
+	now    float64
+	full   float64
+	rate   float64
+	charge bool
+}
+
+// readBatteryLevel reads the capacity and rate of a battery from the energy_* attributes, falling back to
+// the charge_* attributes. The rate is positive regardless of the direction of the current, and 0 if the driver
+// does not report it.
+func readBatteryLevel(batteryName string) (batteryLevel, error) {
+	dir := filepath.Join(powerSupplyDir, batteryName)
+
+	level := batteryLevel{}
+	now, err := intFromFile(filepath.Join(dir, "energy_now"))
+	if errors.Is(err, os.ErrNotExist) {
+		level.charge = true
+		now, err = intFromFile(filepath.Join(dir, "charge_now"))
+	}
+	if err != nil {
+		return batteryLevel{}, fmt.Errorf("failed to get remaining capacity for battery %s: %w", batteryName, err)
+	}
+
+	fullAttr, rateAttr := "energy_full", "power_now"
+	if level.charge {
+		fullAttr, rateAttr = "charge_full", "current_now"
+	}
+	full, err := intFromFile(filepath.Join(dir, fullAttr))
+	if err != nil {
+		return batteryLevel{}, fmt.Errorf("failed to get full capacity for battery %s: %w", batteryName, err)
+	}
+
+	// Some drivers report the rate as negative while discharging
+	if rate, err := intFromFile(filepath.Join(dir, rateAttr)); err == nil {
+		level.rate = math.Abs(float64(rate))
+	}
+	level.now = float64(now)
+	level.full = float64(full)
+
+	return level, nil
+}
+
+// BatteryTime represents the estimated time until a battery is empty or full at the current rate.
+// Fields:
+//   - Status (BatteryStatus): The charging status of the battery.
+//   - TimeToEmpty (time.Duration): The remaining runtime while discharging, 0 otherwise.
+//   - TimeToFull (time.Duration): The time until fully charged while charging, 0 otherwise.
+//
+// Both are 0 when the driver reports no charge or discharge rate, as is common right after plugging in or out.
+type BatteryTime struct {
+	Status      BatteryStatus
+	TimeToEmpty time.Duration
+	TimeToFull  time.Duration
+}
+
+// GetBatteryTime estimates the time until the battery is empty or full from energy_now and power_now,
+// falling back to charge_now and current_now. The estimate follows the instantaneous rate, so it fluctuates
+// with the load.
+func GetBatteryTime(batteryName string) (BatteryTime, error) {
+	status, err := GetBatteryStatus(batteryName)
+	if err != nil {
+		return BatteryTime{}, err
+	}
+
+	level, err := readBatteryLevel(batteryName)
+	if err != nil {
+		return BatteryTime{}, err
+	}
+
+	estimate := BatteryTime{Status: status}
+	if level.rate == 0 {
+		return estimate, nil
+	}
+
+	switch status {
+	case BatteryDischarging:
+		estimate.TimeToEmpty = time.Duration(level.now / level.rate * float64(time.Hour))
+	case BatteryCharging:
+		estimate.TimeToFull = time.Duration(max(level.full-level.now, 0) / level.rate * float64(time.Hour))
+	}
+
+	return estimate, nil
+}
+
+// GetBatteryPower retrieves the power the battery is charging or discharging at in watts, from power_now or,
+// for drivers without it, from voltage_now and current_now. The power is positive in both directions, use
+// GetBatteryStatus to tell them apart.
+func GetBatteryPower(batteryName string) (float64, error) {
+	if batteryName == "" {
+		return 0, fmt.Errorf("battery name cannot be empty")
+	}
+
+	dir := filepath.Join(powerSupplyDir, batteryName)
+	power, err := intFromFile(filepath.Join(dir, "power_now"))
+	if err == nil {
+		return math.Abs(float64(power)) / 1e6, nil
+	}
+	if !errors.Is(err, os.ErrNotExist) {
+		return 0, fmt.Errorf("failed to get power for battery %s: %w", batteryName, err)
+	}
+
+	voltage, err := intFromFile(filepath.Join(dir, "voltage_now"))
+	if err != nil {
+		return 0, fmt.Errorf("failed to get voltage for battery %s: %w", batteryName, err)
+	}
+	current, err := intFromFile(filepath.Join(dir, "current_now"))
+	if err != nil {
+		return 0, fmt.Errorf("failed to get current for battery %s: %w", batteryName, err)
+	}
+
+	// µV times µA is in pW
+	return math.Abs(float64(voltage)*float64(current)) / 1e12, nil
+}
+
+// ErrCycleCountUnsupported is returned when a battery does not expose its charge cycle count.
+var ErrCycleCountUnsupported = errors.New("battery does not expose cycle count")
+
+// GetBatteryCycleCount retrieves the number of charge cycles the battery has gone through from cycle_count.
+// Returns ErrCycleCountUnsupported if the battery does not expose it. Some drivers report 0 when the count is unknown.
+func GetBatteryCycleCount(batteryName string) (int, error) {
+	if batteryName == "" {
+		return 0, fmt.Errorf("battery name cannot be empty")
+	}
+
+	cycles, err := intFromFile(filepath.Join(powerSupplyDir, batteryName, "cycle_count"))
+	if errors.Is(err, os.ErrNotExist) {
+		return 0, ErrCycleCountUnsupported
+	}
+	if err != nil {
+		return 0, fmt.Errorf("failed to get cycle count for battery %s: %w", batteryName, err)
+	}
+
+	return cycles, nil
+}
+
+// BatteryElectrical represents the electrical readings of a battery.
+// Fields:
+//   - VoltageV (float64): The present voltage in volts.
+//   - VoltageMinDesignV (float64): The minimum design voltage in volts, 0 if not exposed.
+//   - CurrentA (float64): The present charge or discharge current in amperes, positive in both directions,
+//     0 if not exposed.
+//
+// A voltage that sags well below the design minimum under load points to a worn or failing pack.
+type BatteryElectrical struct {
+	VoltageV          float64
+	VoltageMinDesignV float64
+	CurrentA          float64
+}
+
+// GetBatteryElectrical retrieves the voltage and current of the battery from voltage_now, voltage_min_design
+// and current_now.
+func GetBatteryElectrical(batteryName string) (BatteryElectrical, error) {
+	if batteryName == "" {
+		return BatteryElectrical{}, fmt.Errorf("battery name cannot be empty")
+	}
+
+	dir := filepath.Join(powerSupplyDir, batteryName)
+	voltage, err := intFromFile(filepath.Join(dir, "voltage_now"))
+	if err != nil {
+		return BatteryElectrical{}, fmt.Errorf("failed to get voltage for battery %s: %w", batteryName, err)
+	}
+
+	electrical := BatteryElectrical{VoltageV: float64(voltage) / 1e6}
+
+	// The remaining attributes are optional and reported in micro units.
+	if voltageMin, err := intFromFile(filepath.Join(dir, "voltage_min_design")); err == nil {
+		electrical.VoltageMinDesignV = float64(voltageMin) / 1e6
+	}
+	if current, err := intFromFile(filepath.Join(dir, "current_now")); err == nil {
+		electrical.CurrentA = math.Abs(float64(current)) / 1e6
+	}
+
+	return electrical, nil
+}
+
+// ErrBatteryTemperatureUnsupported is returned when neither the battery nor a thermal zone reports the battery temperature.
+var ErrBatteryTemperatureUnsupported = errors.New("battery temperature not available")
+
+// GetBatteryTemperature retrieves the temperature of the battery in degrees Celsius from its temp attribute,
+// which is in tenths of a degree. When the battery does not expose it, as on many phones and embedded boards,
+// the temperature is read from a thermal zone measuring the battery, such as "battery" or "bms".
+// Returns ErrBatteryTemperatureUnsupported if neither exists.
+func GetBatteryTemperature(batteryName string) (float64, error) {
+	if batteryName == "" {
+		return 0, fmt.Errorf("battery name cannot be empty")
+	}
+
+	deciCelsius, err := intFromFile(filepath.Join(powerSupplyDir, batteryName, "temp"))
+	if err == nil {
+		return float64(deciCelsius) / 10, nil
+	}
+	if !errors.Is(err, os.ErrNotExist) {
+		return 0, fmt.Errorf("failed to get temperature for battery %s: %w", batteryName, err)
+	}
+
+	zones, err := listThermalZones()
+	if err != nil {
+		return 0, err
+	}
+	for _, zone := range zones {
+		zoneType := strings.ToLower(zone.zoneType)
+		if strings.Contains(zoneType, "bat") || zoneType == "bms" {
+			return readThermalZoneTemp(zone)
+		}
+	}
+
+	return 0, ErrBatteryTemperatureUnsupported
+}
+
+// BatteryInfo represents the static attributes of a battery, as used for inventory.
+// Fields:
+//   - Manufacturer (string): The manufacturer of the battery.
+//   - ModelName (string): The model of the battery.
+//   - SerialNumber (string): The serial number of the battery.
+//   - Technology (string): The cell chemistry, e.g. "Li-ion" or "Li-poly".
+//   - EnergyFullDesignWh (float64): The design energy capacity in watt-hours, 0 if not exposed.
+//   - ChargeFullDesignAh (float64): The design charge capacity in ampere-hours, 0 if not exposed.
+//   - VoltageMinDesignV (float64): The minimum design voltage in volts, 0 if not exposed.
+//
+// The attributes are optional and left empty or zero when the driver does not expose them.
+type BatteryInfo struct {
+	Manufacturer       string
+	ModelName          string
+	SerialNumber       string
+	Technology         string
+	EnergyFullDesignWh float64
+	ChargeFullDesignAh float64
+	VoltageMinDesignV  float64
+}
+
+// GetBatteryInfo retrieves the manufacturer, model, serial number, technology and design capacities of the battery.
+func GetBatteryInfo(batteryName string) (BatteryInfo, error) {
+	if batteryName == "" {
+		return BatteryInfo{}, fmt.Errorf("battery name cannot be empty")
+	}
+
+	dir := filepath.Join(powerSupplyDir, batteryName)
+	if _, err := os.Stat(dir); err != nil {
+		return BatteryInfo{}, fmt.Errorf("failed to get battery info for %s: %w", batteryName, err)
+	}
+
+	var info BatteryInfo
+	for _, attr := range []struct {
+		file  string
+		value *string
+	}{
+		{"manufacturer", &info.Manufacturer},
+		{"model_name", &info.ModelName},
+		{"serial_number", &info.SerialNumber},
+		{"technology", &info.Technology},
+	} {
+		*attr.value, _ = stringFromFile(filepath.Join(dir, attr.file))
+	}
+
+	// The design capacities are reported in micro units.
+	for _, attr := range []struct {
+		file  string
+		value *float64
+	}{
+		{"energy_full_design", &info.EnergyFullDesignWh},
+		{"charge_full_design", &info.ChargeFullDesignAh},
+		{"voltage_min_design", &info.VoltageMinDesignV},
+	} {
+		if value, err := intFromFile(filepath.Join(dir, attr.file)); err == nil {
+			*attr.value = float64(value) / 1e6
+		}
+	}
+
+	return info, nil
 }
@@ -0,0 +1,277 @@
+package resourceutil
+
+import (
+	"fmt"
+	"log/slog"
+	"sync"
+	"time"
+)
+
+const (
+	defaultBatterySampleInterval = 30 * time.Second
+	defaultBatteryWindow         = 240
+)
+
+// BatterySample represents a single battery measurement.
+// Fields:
+//   - Timestamp (time.Time): The time the measurement was taken.
+//   - Status (BatteryStatus): The charging status of the battery.
+//   - SOC (float64): The state of charge as a percentage, calculated from the remaining and full capacity
+//     for more precision than the whole percent of capacity.
+//   - PowerW (float64): The charge or discharge power in watts, 0 if the driver does not report it.
+type BatterySample struct {
+	Timestamp time.Time
+	Status    BatteryStatus
+	SOC       float64
+	PowerW    float64
+}
+
+// BatteryDischargeRate represents the average discharge of a battery over a period of time.
+// Fields:
+//   - Duration (time.Duration): The time between the oldest and newest measurement the rate covers.
+//   - Samples (int): The number of measurements the rate covers.
+//   - PercentPerHour (float64): The average decrease of the state of charge in percent per hour.
+//   - AvgPowerW (float64): The average discharge power in watts, 0 if the driver does not report it.
+//   - TimeToEmpty (time.Duration): The remaining runtime at PercentPerHour, 0 if the state of charge did not drop.
+type BatteryDischargeRate struct {
+	Duration       time.Duration
+	Samples        int
+	PercentPerHour float64
+	AvgPowerW      float64
+	TimeToEmpty    time.Duration
+}
+
+// batteryMeasureOptions holds the settings used by the battery measurement loop.
+type batteryMeasureOptions struct {
+	sampleInterval time.Duration
+	window         int
+}
+
+// BatteryMeasureOption configures NewBatteryMonitor.
+type BatteryMeasureOption func(*batteryMeasureOptions)
+
+// WithBatterySampleInterval sets the time between battery measurements, default 30 s.
+func WithBatterySampleInterval(interval time.Duration) BatteryMeasureOption {
+	return func(o *batteryMeasureOptions) {
+		o.sampleInterval = interval
+	}
+}
+
+// WithBatteryWindow sets the number of measurements kept for the history and discharge rate, default 240,
+// which is two hours at the default sample interval.
+func WithBatteryWindow(samples int) BatteryMeasureOption {
+	return func(o *batteryMeasureOptions) {
+		o.window = samples
+	}
+}
+
+// newBatteryMeasureOptions applies opts over the defaults. Invalid options are logged and replaced by their defaults.
+func newBatteryMeasureOptions(opts []BatteryMeasureOption) batteryMeasureOptions {
+	options := batteryMeasureOptions{
+		sampleInterval: defaultBatterySampleInterval,
+		window:         defaultBatteryWindow,
+	}
+	for _, opt := range opts {
+		opt(&options)
+	}
+	if options.sampleInterval <= 0 {
+		slog.Warn("Invalid battery sample interval, using default", slog.Duration("sample_interval", options.sampleInterval))
+		options.sampleInterval = defaultBatterySampleInterval
+	}
+	if options.window <= 0 {
+		slog.Warn("Invalid battery measurement window, using default", slog.Int("window", options.window))
+		options.window = defaultBatteryWindow
+	}
+
+	return options
+}
+
+// BatteryMonitor measures the state of charge and power of a battery in the background and keeps the
+// measurements of a sliding window, from which it calculates a discharge rate that is far more stable than
+// the instantaneous power_now. The zero value is not usable, create monitors with NewBatteryMonitor.
+type BatteryMonitor struct {
+	name    string
+	options batteryMeasureOptions
+
+	// mu guards the running state of the measurement loop.
+	mu      sync.Mutex
+	running bool
+	stop    chan struct{}
+
+	// samplesMu guards the measurements of the window, newest last.
+	samplesMu sync.Mutex
+	samples   *ringBuffer[BatterySample]
+}
+
+// NewBatteryMonitor creates a monitor of the named battery with the given options. The monitor does not measure
+// until Start is called. Invalid options are logged and replaced by their defaults.
+func NewBatteryMonitor(batteryName string, opts ...BatteryMeasureOption) (*BatteryMonitor, error) {
+	if batteryName == "" {
+		return nil, fmt.Errorf("battery name cannot be empty")
+	}
+
+	return &BatteryMonitor{name: batteryName, options: newBatteryMeasureOptions(opts)}, nil
+}
+
+// Start starts the goroutine that measures the battery. The first measurement is taken immediately and
+// measurements of a previous run are discarded.
+func (m *BatteryMonitor) Start() {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if m.running {
+		slog.Warn("Unable to start battery measurement as it is already started", slog.String("battery", m.name))
+		return
+	}
+	m.running = true
+
+	m.samplesMu.Lock()
+	m.samples = newRingBuffer[BatterySample](m.options.window)
+	m.samplesMu.Unlock()
+
+	m.stop = make(chan struct{})
+	go m.measureLoop(m.stop)
+}
+
+// Stop stops the goroutine that measures the battery. The monitor can be started again with Start.
+func (m *BatteryMonitor) Stop() {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if !m.running {
+		slog.Warn("Unable to stop battery measurement as it is not started", slog.String("battery", m.name))
+		return
+	}
+	m.running = false
+	close(m.stop)
+}
+
+// measureLoop measures the battery every sample interval until stop is closed.
+// Failed measurements are logged and skipped.
+func (m *BatteryMonitor) measureLoop(stop <-chan struct{}) {
+	ticker := time.NewTicker(m.options.sampleInterval)
+	defer ticker.Stop()
+
+	for {
+		sample, err := m.measure()
+		if err != nil {
+			slog.Error("Failed to measure battery", slog.String("battery", m.name), slog.Any("error", err))
+		} else {
+			// Holding mu ensures the sample is not pushed into the buffer of a run started after stopping
+			m.mu.Lock()
+			select {
+			case <-stop:
+				m.mu.Unlock()
+				return
+			default:
+			}
+			m.samplesMu.Lock()
+			m.samples.push(sample)
+			m.samplesMu.Unlock()
+			m.mu.Unlock()
+			slog.Debug("Measured battery", slog.String("battery", m.name), slog.Float64("soc", sample.SOC), slog.Float64("power_w", sample.PowerW))
+		}
+
+		select {
+		case <-stop:
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// measure takes a single measurement of the battery.
+func (m *BatteryMonitor) measure() (BatterySample, error) {
+	level, err := readBatteryLevel(m.name)
+	if err != nil {
+		return BatterySample{}, err
+	}
+	if level.full <= 0 {
+		return BatterySample{}, fmt.Errorf("invalid full capacity for battery %s", m.name)
+	}
+	status, err := GetBatteryStatus(m.name)
+	if err != nil {
+		return BatterySample{}, err
+	}
+	power, err := GetBatteryPower(m.name)
+	if err != nil {
+		slog.Debug("Unable to read battery power", slog.String("battery", m.name), slog.Any("error", err))
+	}
+
+	return BatterySample{
+		Timestamp: time.Now(),
+		Status:    status,
+		SOC:       min(level.now/level.full*100, 100),
+		PowerW:    power,
+	}, nil
+}
+
+// History retrieves the measurements in the window, oldest first.
+// Throws an error if the monitor has not started.
+func (m *BatteryMonitor) History() ([]BatterySample, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if !m.running {
+		return nil, fmt.Errorf("battery measurement loop has not started, start measurement before trying to read history")
+	}
+
+	m.samplesMu.Lock()
+	defer m.samplesMu.Unlock()
+
+	return m.samples.oldestFirst(m.samples.len()), nil
+}
+
+// DischargeRate calculates the average discharge rate over the measurements of the last period, e.g. the
+// last 10 minutes for a responsive or the last hour for a stable estimate. Only the measurements since the
+// battery last started discharging are used, so plugging in a charger resets the rate.
+// Throws an error if the monitor has not started or the battery has not been discharging for at least two
+// measurements within the period.
+func (m *BatteryMonitor) DischargeRate(period time.Duration) (BatteryDischargeRate, error) {
+	if period <= 0 {
+		return BatteryDischargeRate{}, fmt.Errorf("period must be positive, got %s", period)
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if !m.running {
+		return BatteryDischargeRate{}, fmt.Errorf("battery measurement loop has not started, start measurement before trying to read discharge rate")
+	}
+
+	m.samplesMu.Lock()
+	defer m.samplesMu.Unlock()
+
+	n := m.samples.len()
+	if n == 0 {
+		return BatteryDischargeRate{}, fmt.Errorf("no battery measurement has completed yet")
+	}
+
+	newest := m.samples.newest(0)
+	oldest := newest
+	count := 0
+	power := 0.0
+	for i := range n {
+		sample := m.samples.newest(i)
+		if sample.Status != BatteryDischarging || newest.Timestamp.Sub(sample.Timestamp) > period {
+			break
+		}
+		oldest = sample
+		count++
+		power += sample.PowerW
+	}
+	if count < 2 {
+		return BatteryDischargeRate{}, fmt.Errorf("battery %s has not been discharging for long enough to calculate a rate", m.name)
+	}
+
+	duration := newest.Timestamp.Sub(oldest.Timestamp)
+	rate := BatteryDischargeRate{
+		Duration:       duration,
+		Samples:        count,
+		PercentPerHour: max(oldest.SOC-newest.SOC, 0) / duration.Hours(),
+		AvgPowerW:      power / float64(count),
+	}
+	if rate.PercentPerHour > 0 {
+		rate.TimeToEmpty = time.Duration(newest.SOC / rate.PercentPerHour * float64(time.Hour))
+	}
+
+	return rate, nil
+}
@@ -0,0 +1,87 @@
+package resourceutil
+
+import (
+	"context"
+	"log/slog"
+	"time"
+)
+
+const (
+	// batteryPollInterval is how often OnBatteryBelow reads the state of charge in addition to uevents,
+	// as not every driver emits an event for each change of the capacity.
+	batteryPollInterval = time.Minute
+	// batteryHysteresis is how many percent the state of charge must rise above the threshold before
+	// OnBatteryBelow fires again, so a charge fluctuating around the threshold fires only once.
+	batteryHysteresis = 2
+)
+
+// OnBatteryBelow calls fn once the state of charge of the battery drops below percent, e.g. to warn the user or
+// shut down cleanly. fn receives the state of charge and is called again only after it has risen to at least
+// percent plus a hysteresis of 2 and dropped below percent again. Changes are picked up from the power supply
+// uevents of WatchBattery as they happen, with a poll every minute for drivers that do not emit them.
+// fn runs on its own goroutine, one call at a time. The returned function cancels the callback.
+func OnBatteryBelow(name string, percent int, fn func(soc int)) func() {
+	ctx, cancel := context.WithCancel(context.Background())
+
+	events, err := WatchBattery(ctx)
+	if err != nil {
+		slog.Warn("Unable to watch battery uevents, falling back to polling", slog.String("battery", name), slog.Any("error", err))
+	}
+
+	go func() {
+		ticker := time.NewTicker(batteryPollInterval)
+		defer ticker.Stop()
+
+		fired := false
+		check := func(soc int) {
+			if soc >= percent+batteryHysteresis {
+				fired = false
+				return
+			}
+			if !fired && soc < percent {
+				fired = true
+				slog.Debug("Battery dropped below threshold", slog.String("battery", name), slog.Int("threshold", percent), slog.Int("soc", soc))
+				fn(soc)
+			}
+		}
+		poll := func() {
+			soc, err := GetBatterySOC(name)
+			if err != nil {
+				slog.Warn("Failed to get battery SOC", slog.String("battery", name), slog.Any("error", err))
+				return
+			}
+			check(soc)
+		}
+
+		poll()
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+				poll()
+			case event, ok := <-events:
+				if !ok {
+					// The watcher failed, keep polling
+					events = nil
+					continue
+				}
+				if event.Lost {
+					// The event for the battery may have been among the lost ones
+					poll()
+					continue
+				}
+				if event.Name != name {
+					continue
+				}
+				if event.Capacity >= 0 {
+					check(event.Capacity)
+				} else {
+					poll()
+				}
+			}
+		}
+	}()
+
+	return cancel
+}
@@ -0,0 +1,124 @@
+package resourceutil
+
+import (
+	"errors"
+	"fmt"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"strconv"
+)
+
+// ErrChargeThresholdsUnsupported is returned when a battery does not expose charge control thresholds.
+var ErrChargeThresholdsUnsupported = errors.New("battery does not support charge thresholds")
+
+// ChargeThresholds represents the charge control thresholds of a battery, which keep it from charging to 100%
+// to preserve its health.
+// Fields:
+//   - Start (int): The state of charge in percent below which charging starts, 0 if the battery only supports an end threshold.
+//   - End (int): The state of charge in percent at which charging stops.
+type ChargeThresholds struct {
+	Start int
+	End   int
+}
+
+// chargeThresholdFiles returns the paths of the start and end threshold attributes of a battery, preferring the
+// generic charge_control_* names over the older charge_start_threshold and charge_stop_threshold of ThinkPads.
+// The start path is empty if the battery only supports an end threshold.
+func chargeThresholdFiles(batteryName string) (start, end string, err error) {
+	dir := filepath.Join(powerSupplyDir, batteryName)
+	for _, names := range [][2]string{
+		{"charge_control_start_threshold", "charge_control_end_threshold"},
+		{"charge_start_threshold", "charge_stop_threshold"},
+	} {
+		end = filepath.Join(dir, names[1])
+		if _, err := os.Stat(end); err != nil {
+			continue
+		}
+		start = filepath.Join(dir, names[0])
+		if _, err := os.Stat(start); err != nil {
+			start = ""
+		}
+		return start, end, nil
+	}
+
+	return "", "", ErrChargeThresholdsUnsupported
+}
+
+// GetBatteryChargeThresholds retrieves the charge control thresholds of the battery.
+// Returns ErrChargeThresholdsUnsupported if the battery does not expose them.
+func GetBatteryChargeThresholds(batteryName string) (ChargeThresholds, error) {
+	if batteryName == "" {
+		return ChargeThresholds{}, fmt.Errorf("battery name cannot be empty")
+	}
+
+	startPath, endPath, err := chargeThresholdFiles(batteryName)
+	if err != nil {
+		return ChargeThresholds{}, err
+	}
+
+	var thresholds ChargeThresholds
+	if thresholds.End, err = intFromFile(endPath); err != nil {
+		return ChargeThresholds{}, fmt.Errorf("failed to get charge end threshold for battery %s: %w", batteryName, err)
+	}
+	if startPath != "" {
+		if thresholds.Start, err = intFromFile(startPath); err != nil {
+			return ChargeThresholds{}, fmt.Errorf("failed to get charge start threshold for battery %s: %w", batteryName, err)
+		}
+	}
+
+	return thresholds, nil
+}
+
+// SetBatteryChargeThresholds sets the charge control thresholds of the battery, e.g. a start of 75 and an end of
+// 80 to keep a permanently plugged-in battery between those. This requires root privileges. The start threshold
+// is ignored for batteries that only support an end threshold, pass 0 for those.
+// Returns ErrChargeThresholdsUnsupported if the battery does not expose charge thresholds.
+func SetBatteryChargeThresholds(batteryName string, start, end int) error {
+	if batteryName == "" {
+		return fmt.Errorf("battery name cannot be empty")
+	}
+	if end < 1 || end > 100 || start < 0 || start >= end {
+		return fmt.Errorf("invalid charge thresholds, start %d must be below end %d and end within 1-100", start, end)
+	}
+
+	startPath, endPath, err := chargeThresholdFiles(batteryName)
+	if err != nil {
+		return err
+	}
+	if startPath == "" && start != 0 {
+		slog.Warn("Ignoring charge start threshold as the battery only supports an end threshold", slog.String("battery", batteryName), slog.Int("start", start))
+	}
+
+	write := func(path string, value int) error {
+		if err := os.WriteFile(path, []byte(strconv.Itoa(value)), 0o644); err != nil {
+			return fmt.Errorf("failed to set charge threshold for battery %s: %w", batteryName, err)
+		}
+		return nil
+	}
+
+	// Drivers reject a start threshold at or above the current end threshold and vice versa,
+	// so raise the end threshold first and lower it last.
+	current, err := GetBatteryChargeThresholds(batteryName)
+	if err != nil {
+		return err
+	}
+	if startPath == "" {
+		err = write(endPath, end)
+	} else if end > current.End {
+		if err = write(endPath, end); err == nil {
+			err = write(startPath, start)
+		}
+	} else {
+		if err = write(startPath, start); err == nil {
+			err = write(endPath, end)
+		}
+	}
+	if err != nil {
+		return err
+	}
+
+	slog.Info("Set battery charge thresholds", slog.String("battery", batteryName), slog.Int("start", start), slog.Int("end", end))
+
+	return nil
+}
@@ -0,0 +1,151 @@
+package resourceutil
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"os"
+	"path"
+	"strconv"
+	"syscall"
+	"time"
+)
+
+const (
+	// netlinkKobjectUevent is the netlink protocol of kernel uevents, which the syscall package does not define.
+	netlinkKobjectUevent = 15
+	// ueventKernelGroup is the multicast group the kernel sends uevents to.
+	ueventKernelGroup = 1
+	// maxUeventSize is the largest uevent message the kernel sends.
+	maxUeventSize = 8192
+)
+
+// BatteryEvent represents a change of a power supply reported by the kernel.
+// Fields:
+//   - Timestamp (time.Time): The time the event was received.
+//   - Name (string): The sysfs name of the power supply, e.g. "BAT0" or "AC".
+//   - Type (PowerSupplyType): The kind of power supply.
+//   - Status (BatteryStatus): The charging status of a battery, empty for other power supplies.
+//   - Capacity (int): The state of charge of a battery as a percentage, -1 if not reported.
+//   - Online (bool): Whether external power is connected, for Mains and USB power supplies.
+//   - Properties (map[string]string): All POWER_SUPPLY_* properties of the event without the prefix,
+//     e.g. "ENERGY_NOW".
+//   - Lost (bool): Whether this event only reports that events were lost, because a burst of uevents overflowed
+//     the socket buffer. All other fields except Timestamp are empty, re-read the power supplies of interest.
+type BatteryEvent struct {
+	Timestamp  time.Time
+	Name       string
+	Type       PowerSupplyType
+	Status     BatteryStatus
+	Capacity   int
+	Online     bool
+	Properties map[string]string
+	Lost       bool
+}
+
+// WatchBattery subscribes to the uevents the kernel emits when the properties of a power supply change, such as
+// its status, capacity or a charger being plugged in, and sends them on the returned channel until ctx is
+// cancelled, after which the channel is closed. Unlike polling this costs nothing while nothing changes, but how
+// often drivers emit events varies: most report status changes right away and capacity changes every percent or
+// at regular intervals. Events of all power supplies are delivered, filter by Name or Type as needed. When a burst
+// of uevents overflows the socket buffer an event with Lost set is sent and watching continues.
+func WatchBattery(ctx context.Context) (<-chan BatteryEvent, error) {
+	fd, err := syscall.Socket(syscall.AF_NETLINK, syscall.SOCK_DGRAM|syscall.SOCK_CLOEXEC|syscall.SOCK_NONBLOCK, netlinkKobjectUevent)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open uevent socket: %w", err)
+	}
+	if err := syscall.Bind(fd, &syscall.SockaddrNetlink{Family: syscall.AF_NETLINK, Groups: ueventKernelGroup}); err != nil {
+		syscall.Close(fd)
+		return nil, fmt.Errorf("failed to subscribe to uevents: %w", err)
+	}
+	// A non-blocking file is registered with the runtime poller, so closing it interrupts a pending read
+	socket := os.NewFile(uintptr(fd), "uevent")
+
+	events := make(chan BatteryEvent, 16)
+
+	go func() {
+		<-ctx.Done()
+		socket.Close()
+	}()
+
+	go func() {
+		defer close(events)
+
+		buf := make([]byte, maxUeventSize)
+		for {
+			var event BatteryEvent
+			n, err := socket.Read(buf)
+			switch {
+			case errors.Is(err, syscall.ENOBUFS):
+				// Uevents arrived faster than they were read, e.g. while docking, the socket keeps working
+				slog.Warn("Lost power supply events as the uevent socket buffer overflowed")
+				event = BatteryEvent{Timestamp: time.Now(), Capacity: -1, Lost: true}
+			case err != nil:
+				if ctx.Err() == nil && !errors.Is(err, os.ErrClosed) {
+					slog.Error("Failed to read uevent", slog.Any("error", err))
+				}
+				return
+			default:
+				var ok bool
+				if event, ok = parsePowerSupplyUevent(buf[:n]); !ok {
+					continue
+				}
+			}
+			slog.Debug("Received power supply event", slog.String("name", event.Name), slog.String("status", string(event.Status)), slog.Int("capacity", event.Capacity))
+
+			select {
+			case events <- event:
+			case <-ctx.Done():
+				return
+			}
+		}
+	}()
+
+	return events, nil
+}
+
+// parsePowerSupplyUevent parses a kernel uevent of the form "action@devpath\0KEY=value\0...".
+// Returns false for uevents of other subsystems.
+func parsePowerSupplyUevent(msg []byte) (BatteryEvent, bool) {
+	fields := bytes.Split(msg, []byte{0})
+	if len(fields) < 2 {
+		return BatteryEvent{}, false
+	}
+
+	event := BatteryEvent{Timestamp: time.Now(), Capacity: -1, Properties: make(map[string]string)}
+	subsystem, devPath := "", ""
+	for _, field := range fields[1:] {
+		key, value, ok := bytes.Cut(field, []byte("="))
+		if !ok {
+			continue
+		}
+		switch string(key) {
+		case "SUBSYSTEM":
+			subsystem = string(value)
+		case "DEVPATH":
+			devPath = string(value)
+		default:
+			if name, ok := bytes.CutPrefix(key, []byte("POWER_SUPPLY_")); ok {
+				event.Properties[string(name)] = string(value)
+			}
+		}
+	}
+	if subsystem != "power_supply" {
+		return BatteryEvent{}, false
+	}
+
+	event.Name = event.Properties["NAME"]
+	if event.Name == "" {
+		event.Name = path.Base(devPath)
+	}
+	event.Type = PowerSupplyType(event.Properties["TYPE"])
+	event.Status = BatteryStatus(event.Properties["STATUS"])
+	if capacity, err := strconv.Atoi(event.Properties["CAPACITY"]); err == nil {
+		event.Capacity = capacity
+	}
+	event.Online = event.Properties["ONLINE"] == "1"
+
+	return event, true
+}
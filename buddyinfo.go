@@ -0,0 +1,103 @@
+package resourceutil
+
+import (
+	"bufio"
+	"fmt"
+	"log/slog"
+	"os"
+	"strconv"
+	"strings"
+)
+
+// BuddyZone represents the free pages of a memory zone by allocation order, from /proc/buddyinfo.
+// Fields:
+//   - Node (int): The NUMA node of the zone.
+//   - Zone (string): The name of the zone, e.g. "DMA32" or "Normal".
+//   - FreeBlocks ([]uint64): The number of free blocks of each order, indexed by order. A block of order n
+//     has 2^n contiguous pages.
+//   - FreeGB (float64): The free memory of the zone in gigabytes.
+//   - UnusableIndex ([]float64): The fraction of the free memory that cannot satisfy an allocation of each order,
+//     indexed by order, from 0 when every free page is in a large enough block to 1 when none is.
+//
+// A high unusable index for the orders of huge pages or the larger allocations of drivers means those allocations
+// fail, or stall on compaction, even though plenty of memory is free.
+type BuddyZone struct {
+	Node          int
+	Zone          string
+	FreeBlocks    []uint64
+	FreeGB        float64
+	UnusableIndex []float64
+}
+
+// GetBuddyInfo retrieves the free pages of each memory zone by order from /proc/buddyinfo.
+func GetBuddyInfo() ([]BuddyZone, error) {
+	file, err := os.Open("/proc/buddyinfo")
+	if err != nil {
+		slog.Error("Failed to read buddy info", slog.String("path", "/proc/buddyinfo"), slog.Any("error", err))
+		return nil, err
+	}
+	defer file.Close()
+
+	const bytesPerGB = 1024 * 1024 * 1024
+	pageSize := uint64(os.Getpagesize())
+
+	zones := []BuddyZone{}
+	scanner := bufio.NewScanner(file)
+	for scanner.Scan() {
+		// Format: Node 0, zone   Normal   3848    301      8 ...
+		fields := strings.Fields(scanner.Text())
+		if len(fields) < 5 || fields[0] != "Node" || fields[2] != "zone" {
+			return nil, fmt.Errorf("unexpected line in /proc/buddyinfo: %s", scanner.Text())
+		}
+
+		node, err := strconv.Atoi(strings.TrimSuffix(fields[1], ","))
+		if err != nil {
+			return nil, fmt.Errorf("failed to parse node in /proc/buddyinfo: %w", err)
+		}
+
+		zone := BuddyZone{Node: node, Zone: fields[3], FreeBlocks: make([]uint64, 0, len(fields)-4)}
+		freePages := uint64(0)
+		for order, field := range fields[4:] {
+			blocks, err := strconv.ParseUint(field, 10, 64)
+			if err != nil {
+				return nil, fmt.Errorf("failed to parse free blocks of order %d in zone %s: %w", order, zone.Zone, err)
+			}
+			zone.FreeBlocks = append(zone.FreeBlocks, blocks)
+			freePages += blocks << order
+		}
+		zone.FreeGB = float64(freePages*pageSize) / bytesPerGB
+		zone.UnusableIndex = unusableIndex(zone.FreeBlocks, freePages)
+
+		zones = append(zones, zone)
+	}
+
+	if err := scanner.Err(); err != nil {
+		slog.Error("Failed to scan /proc/buddyinfo", slog.Any("error", err))
+		return nil, err
+	}
+
+	slog.Debug("Got buddy info", slog.Any("buddy_zones", zones))
+
+	return zones, nil
+}
+
+// unusableIndex calculates for each order the fraction of the free pages in blocks too small for an allocation
+// of that order, the unusable free space index of the kernel. Zones without free pages are fully unusable.
+func unusableIndex(freeBlocks []uint64, freePages uint64) []float64 {
+	index := make([]float64, len(freeBlocks))
+	if freePages == 0 {
+		for order := range index {
+			index[order] = 1
+		}
+		return index
+	}
+
+	// Pages in blocks smaller than the order, accumulated from the smallest order
+	smaller := uint64(0)
+	for order, blocks := range freeBlocks {
+		index[order] = float64(smaller) / float64(freePages)
+		smaller += blocks << order
+	}
+
+	return index
+}
@@ -0,0 +1,435 @@
+package resourceutil
+
+import (
+	"errors"
+	"fmt"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// cgroupRoot is the mount point of the cgroup hierarchies.
+const cgroupRoot = "/sys/fs/cgroup"
+
+// cgroupV1Unlimited is the smallest value treated as "no limit" in cgroup v1 limit files,
+// which report unlimited as the largest page-aligned int64.
+const cgroupV1Unlimited = 1 << 62
+
+// ErrCgroupNotFound is returned when the requested cgroup does not exist.
+var ErrCgroupNotFound = errors.New("cgroup not found")
+
+// cgroupMemory holds the memory usage and limit of a cgroup in bytes.
+// A limit of zero means the cgroup is unlimited. cache is the page cache charged to the cgroup from memory.stat,
+// zero if memory.stat is unavailable.
+type cgroupMemory struct {
+	current uint64
+	limit   uint64
+	cache   uint64
+}
+
+// readCgroupMemory reads the memory usage and limit of the cgroup at the given path relative to the hierarchy root.
+// The cgroup v2 unified hierarchy is tried first, with a fallback to the cgroup v1 memory controller.
+func readCgroupMemory(cgroupPath string) (cgroupMemory, error) {
+	if dir, ok := cgroupV2Dir(cgroupPath); ok {
+		if _, err := os.Stat(filepath.Join(dir, "memory.current")); err == nil {
+			return readCgroupV2Memory(dir)
+		}
+	}
+
+	dir := filepath.Join(cgroupRoot, "memory", cgroupPath)
+	if _, err := os.Stat(dir); err != nil {
+		return cgroupMemory{}, fmt.Errorf("%w: %s", ErrCgroupNotFound, cgroupPath)
+	}
+
+	return readCgroupV1Memory(dir)
+}
+
+// readSelfCgroupMemory reads the memory usage and limit of the cgroup of the calling process.
+// The cgroup v2 unified hierarchy is used when it has the memory controller, with a fallback to the
+// cgroup v1 memory controller.
+func readSelfCgroupMemory() (cgroupMemory, error) {
+	paths, err := selfCgroupPaths()
+	if err != nil {
+		return cgroupMemory{}, err
+	}
+
+	if v2Path, ok := paths[""]; ok {
+		if dir, ok := cgroupV2Dir(v2Path); ok {
+			// The root cgroup has no memory.current, so fall through to v1 or fail below
+			if _, err := os.Stat(filepath.Join(dir, "memory.current")); err == nil {
+				return readCgroupV2Memory(dir)
+			}
+		}
+	}
+
+	dir, err := cgroupV1Dir("memory", paths["memory"])
+	if err != nil {
+		return cgroupMemory{}, err
+	}
+
+	return readCgroupV1Memory(dir)
+}
+
+// readCgroupV2Memory reads memory.current, memory.max and the file cache from memory.stat of a cgroup v2 directory.
+func readCgroupV2Memory(dir string) (cgroupMemory, error) {
+	current, err := uint64FromFile(filepath.Join(dir, "memory.current"))
+	if err != nil {
+		return cgroupMemory{}, err
+	}
+	limit, err := cgroupLimitFromFile(filepath.Join(dir, "memory.max"))
+	if err != nil {
+		return cgroupMemory{}, err
+	}
+	stat, _ := readCgroupMemoryStat(dir)
+
+	return cgroupMemory{current: current, limit: limit, cache: stat["file"]}, nil
+}
+
+// readCgroupV1Memory reads memory.usage_in_bytes, memory.limit_in_bytes and the cache from memory.stat
+// of a cgroup v1 memory controller directory.
+func readCgroupV1Memory(dir string) (cgroupMemory, error) {
+	current, err := uint64FromFile(filepath.Join(dir, "memory.usage_in_bytes"))
+	if err != nil {
+		return cgroupMemory{}, err
+	}
+	limit, err := cgroupLimitFromFile(filepath.Join(dir, "memory.limit_in_bytes"))
+	if err != nil {
+		return cgroupMemory{}, err
+	}
+	stat, _ := readCgroupMemoryStat(dir)
+
+	// The total_ counters include child cgroups, like usage_in_bytes
+	return cgroupMemory{current: current, limit: limit, cache: stat["total_cache"]}, nil
+}
+
+// readCgroupMemoryStat parses the "key value" lines of memory.stat in a cgroup directory.
+func readCgroupMemoryStat(dir string) (map[string]uint64, error) {
+	data, err := stringFromFile(filepath.Join(dir, "memory.stat"))
+	if err != nil {
+		return nil, err
+	}
+
+	stat := make(map[string]uint64)
+	for _, line := range strings.Split(data, "\n") {
+		key, valueStr, ok := strings.Cut(line, " ")
+		if !ok {
+			continue
+		}
+		if value, err := strconv.ParseUint(valueStr, 10, 64); err == nil {
+			stat[key] = value
+		}
+	}
+
+	return stat, nil
+}
+
+// cgroupV2Dir returns the directory of a cgroup in the v2 unified hierarchy, if it exists.
+// Both pure v2 systems and the unified mount of hybrid systems are supported.
+func cgroupV2Dir(cgroupPath string) (string, bool) {
+	for _, root := range []string{cgroupRoot, filepath.Join(cgroupRoot, "unified")} {
+		if _, err := os.Stat(filepath.Join(root, "cgroup.controllers")); err != nil {
+			continue
+		}
+		dir := filepath.Join(root, cgroupPath)
+		if _, err := os.Stat(dir); err == nil {
+			return dir, true
+		}
+	}
+
+	return "", false
+}
+
+// cgroupMemUsage converts cgroup memory figures into a MemUsage.
+// Unlimited cgroups are reported relative to the total memory of the host.
+func cgroupMemUsage(mem cgroupMemory) (MemUsage, error) {
+	total := mem.limit
+	if total == 0 {
+		info, err := GetMemInfo()
+		if err != nil {
+			return MemUsage{}, err
+		}
+		total = info.MemTotal
+	}
+
+	if total == 0 {
+		return MemUsage{}, errors.New("divide by zero: total memory is zero")
+	}
+
+	available := total - min(mem.current, total)
+
+	const bytesPerGB = 1024 * 1024 * 1024
+	return MemUsage{
+		TotalGB:     float64(total) / bytesPerGB,
+		AvailableGB: float64(available) / bytesPerGB,
+		FreeGB:      float64(available) / bytesPerGB,
+		UsedGB:      float64(mem.current) / bytesPerGB,
+		UsedPercent: 100 * float64(mem.current) / float64(total),
+		CachedGB:    float64(mem.cache) / bytesPerGB,
+
+		TotalBytes:     total,
+		AvailableBytes: available,
+		FreeBytes:      available,
+		UsedBytes:      mem.current,
+		CachedBytes:    mem.cache,
+	}, nil
+}
+
+// GetSliceMemUsage retrieves the memory usage of a systemd slice, e.g. "system.slice" or "user-1000.slice".
+//
+// Nested slices are resolved following the systemd naming scheme, so "user-1000.slice" is read from
+// user.slice/user-1000.slice. A slash-separated path relative to the cgroup root is also accepted.
+// If the slice has no memory limit, TotalGB is the total memory of the host. The root slice "-.slice" reports
+// the memory usage of the host, as GetMemUsage does. Returns ErrCgroupNotFound if the slice does not exist.
+func GetSliceMemUsage(slice string) (MemUsage, error) {
+	cgroupPath, err := sliceCgroupPath(slice)
+	if err != nil {
+		return MemUsage{}, err
+	}
+	if cgroupPath == "" {
+		// The root cgroup has no memory.current on cgroup v2 and covers the whole host anyway
+		info, err := GetMemInfo()
+		if err != nil {
+			return MemUsage{}, err
+		}
+		return hostMemUsage(info)
+	}
+
+	mem, err := readCgroupMemory(cgroupPath)
+	if err != nil {
+		return MemUsage{}, fmt.Errorf("failed to get memory usage for slice %s: %w", slice, err)
+	}
+
+	return cgroupMemUsage(mem)
+}
+
+// sliceCgroupPath resolves a systemd slice name to its path relative to the cgroup root.
+func sliceCgroupPath(slice string) (string, error) {
+	if slice == "" {
+		return "", fmt.Errorf("slice name cannot be empty")
+	}
+
+	if strings.Contains(slice, "/") {
+		return strings.Trim(slice, "/"), nil
+	}
+
+	name, ok := strings.CutSuffix(slice, ".slice")
+	if !ok {
+		return "", fmt.Errorf("invalid slice name %s: missing .slice suffix", slice)
+	}
+
+	// The root slice maps to the root of the hierarchy.
+	if name == "-" {
+		return "", nil
+	}
+
+	// Each dash introduces a level of nesting, e.g. a-b-c.slice is a.slice/a-b.slice/a-b-c.slice.
+	parts := strings.Split(name, "-")
+	segments := make([]string, len(parts))
+	for i := range parts {
+		segments[i] = strings.Join(parts[:i+1], "-") + ".slice"
+	}
+
+	return filepath.Join(segments...), nil
+}
+
+// uint64FromFile reads a file at the specified path and attempts to parse its contents as an unsigned integer.
+func uint64FromFile(path string) (uint64, error) {
+	dataStr, err := stringFromFile(path)
+	if err != nil {
+		return 0, err
+	}
+
+	value, err := strconv.ParseUint(dataStr, 10, 64)
+	if err != nil {
+		return 0, fmt.Errorf("failed to parse uint64 at path %s, error: %w", path, err)
+	}
+
+	return value, nil
+}
+
+// cgroupLimitFromFile reads a cgroup limit file, returning zero when the limit is "max" or the v1 unlimited value.
+func cgroupLimitFromFile(path string) (uint64, error) {
+	dataStr, err := stringFromFile(path)
+	if err != nil {
+		return 0, err
+	}
+
+	if dataStr == "max" {
+		return 0, nil
+	}
+
+	limit, err := strconv.ParseUint(dataStr, 10, 64)
+	if err != nil {
+		return 0, fmt.Errorf("failed to parse cgroup limit at path %s, error: %w", path, err)
+	}
+
+	if limit >= cgroupV1Unlimited {
+		return 0, nil
+	}
+
+	return limit, nil
+}
+
+// cgroupCPU holds the CPU usage and quota of a cgroup.
+// Fields:
+//   - usage (time.Duration): The cumulative CPU time consumed by the cgroup.
+//   - limitCPUs (float64): The CPU quota in number of CPUs, zero if unlimited.
+type cgroupCPU struct {
+	usage     time.Duration
+	limitCPUs float64
+}
+
+// selfCgroupPaths parses /proc/self/cgroup into the cgroup path of the calling process per controller.
+// The path in the cgroup v2 unified hierarchy is stored under the empty controller name.
+func selfCgroupPaths() (map[string]string, error) {
+	data, err := stringFromFile("/proc/self/cgroup")
+	if err != nil {
+		slog.Error("Failed to read cgroup membership", slog.String("path", "/proc/self/cgroup"), slog.Any("error", err))
+		return nil, err
+	}
+
+	paths := make(map[string]string)
+	for _, line := range strings.Split(data, "\n") {
+		// Format: hierarchy-ID:controller-list:cgroup-path
+		parts := strings.SplitN(line, ":", 3)
+		if len(parts) != 3 {
+			continue
+		}
+		if parts[1] == "" {
+			paths[""] = parts[2]
+			continue
+		}
+		for _, controller := range strings.Split(parts[1], ",") {
+			paths[controller] = parts[2]
+		}
+	}
+
+	return paths, nil
+}
+
+// cgroupV1Dir returns the directory of a cgroup in the v1 hierarchy of a controller.
+// Controllers may be co-mounted (e.g. cpu,cpuacct), and inside containers without a cgroup namespace
+// the path of the process may not be visible, in which case the root of the mount is used.
+func cgroupV1Dir(controller, cgroupPath string) (string, error) {
+	mounts := []string{controller}
+	if controller == "cpu" || controller == "cpuacct" {
+		mounts = append(mounts, "cpu,cpuacct", "cpuacct,cpu")
+	}
+
+	for _, mount := range mounts {
+		for _, dir := range []string{filepath.Join(cgroupRoot, mount, cgroupPath), filepath.Join(cgroupRoot, mount)} {
+			if _, err := os.Stat(dir); err == nil {
+				return dir, nil
+			}
+		}
+	}
+
+	return "", fmt.Errorf("%w: %s controller for %s", ErrCgroupNotFound, controller, cgroupPath)
+}
+
+// readCgroupCPU reads the CPU usage and quota of the cgroup of the calling process.
+// The cgroup v2 unified hierarchy is used when it has the cpu controller, with a fallback to the
+// cgroup v1 cpuacct and cpu controllers.
+func readCgroupCPU() (cgroupCPU, error) {
+	paths, err := selfCgroupPaths()
+	if err != nil {
+		return cgroupCPU{}, err
+	}
+
+	if v2Path, ok := paths[""]; ok {
+		if dir, ok := cgroupV2Dir(v2Path); ok {
+			controllers, err := stringFromFile(filepath.Join(dir, "cgroup.controllers"))
+			if err == nil && slices.Contains(strings.Fields(controllers), "cpu") {
+				return readCgroupV2CPU(dir)
+			}
+		}
+	}
+
+	usageDir, err := cgroupV1Dir("cpuacct", paths["cpuacct"])
+	if err != nil {
+		return cgroupCPU{}, err
+	}
+	usageNanoseconds, err := uint64FromFile(filepath.Join(usageDir, "cpuacct.usage"))
+	if err != nil {
+		return cgroupCPU{}, err
+	}
+	cpu := cgroupCPU{usage: time.Duration(usageNanoseconds)}
+
+	quotaDir, err := cgroupV1Dir("cpu", paths["cpu"])
+	if err != nil {
+		return cgroupCPU{}, err
+	}
+	// A quota of -1 means unlimited
+	quota, err := intFromFile(filepath.Join(quotaDir, "cpu.cfs_quota_us"))
+	if err != nil {
+		return cgroupCPU{}, err
+	}
+	period, err := intFromFile(filepath.Join(quotaDir, "cpu.cfs_period_us"))
+	if err != nil {
+		return cgroupCPU{}, err
+	}
+	if quota > 0 && period > 0 {
+		cpu.limitCPUs = float64(quota) / float64(period)
+	}
+
+	return cpu, nil
+}
+
+// readCgroupV2CPU reads the CPU usage from cpu.stat and the quota from cpu.max of a cgroup v2 directory.
+func readCgroupV2CPU(dir string) (cgroupCPU, error) {
+	stat, err := stringFromFile(filepath.Join(dir, "cpu.stat"))
+	if err != nil {
+		return cgroupCPU{}, err
+	}
+
+	var cpu cgroupCPU
+	found := false
+	for _, line := range strings.Split(stat, "\n") {
+		value, ok := strings.CutPrefix(line, "usage_usec ")
+		if !ok {
+			continue
+		}
+		usageMicroseconds, err := strconv.ParseUint(value, 10, 64)
+		if err != nil {
+			return cgroupCPU{}, fmt.Errorf("failed to parse usage_usec in %s: %w", dir, err)
+		}
+		cpu.usage = time.Duration(usageMicroseconds) * time.Microsecond
+		found = true
+		break
+	}
+	if !found {
+		return cgroupCPU{}, fmt.Errorf("no usage_usec found in %s/cpu.stat", dir)
+	}
+
+	// Format: "$MAX $PERIOD", where $MAX is "max" when unlimited. The root cgroup has no cpu.max.
+	cpuMax, err := stringFromFile(filepath.Join(dir, "cpu.max"))
+	if err != nil {
+		if errors.Is(err, os.ErrNotExist) {
+			return cpu, nil
+		}
+		return cgroupCPU{}, err
+	}
+	fields := strings.Fields(cpuMax)
+	if len(fields) != 2 {
+		return cgroupCPU{}, fmt.Errorf("unexpected format in %s/cpu.max: %s", dir, cpuMax)
+	}
+	if fields[0] != "max" {
+		quota, err := strconv.ParseFloat(fields[0], 64)
+		if err != nil {
+			return cgroupCPU{}, fmt.Errorf("failed to parse quota in %s/cpu.max: %w", dir, err)
+		}
+		period, err := strconv.ParseFloat(fields[1], 64)
+		if err != nil {
+			return cgroupCPU{}, fmt.Errorf("failed to parse period in %s/cpu.max: %w", dir, err)
+		}
+		if period > 0 {
+			cpu.limitCPUs = quota / period
+		}
+	}
+
+	return cpu, nil
+}
@@ -1,159 +1,1009 @@
 package resourceutil
 
 import (
-	"bufio"
+	"context"
+	"errors"
 	"fmt"
 	"log/slog"
-	"os"
-	"strconv"
-	"strings"
+	"math"
 	"sync"
+	"sync/atomic"
+	"syscall"
 	"time"
+	"unsafe"
 )
 
-var (
-	loadMeasurements      [10]float64
-	loadMeasurementsMutex sync.Mutex
-	isMeasuring           bool
-	measurementMutex      sync.Mutex
+const (
+	// watchdogInterval is how often the watchdog checks that the measurement loop is producing samples.
+	watchdogInterval = time.Second
+	// staleSampleAge is the age of the last sample after which the measurement loop is considered dead.
+	staleSampleAge = 5 * time.Second
+	// watchdogMaxRestarts is the number of restarts after which the watchdog gives up.
+	watchdogMaxRestarts = 5
+	// watchdogBaseBackoff is the minimum time between restarts, doubled after every restart.
+	watchdogBaseBackoff = time.Second
+	// watchdogMaxBackoff caps the time between restarts.
+	watchdogMaxBackoff = time.Minute
+
+	// minSuspendGap is the time the system must have been suspended during a measurement for it to be discarded.
+	minSuspendGap = time.Second
+
+	defaultSampleInterval = 100 * time.Millisecond
+	defaultWindow         = 10
+
+	// Clock IDs for clock_gettime(2), which the syscall package does not define.
+	clockMonotonic = 1
+	clockBoottime  = 7
+)
+
+// ErrCPULoadStale is returned when the last CPU load measurement is older than the threshold set with WithStaleAfter.
+var ErrCPULoadStale = errors.New("CPU load measurement is stale")
+
+// CPULoadSample represents a single CPU load measurement.
+// Fields:
+//   - Timestamp (time.Time): The time the measurement completed.
+//   - Load (float64): The CPU load in percent over the sample interval.
+//   - Steal (float64): The percentage of the sample interval stolen by the hypervisor for other virtual machines.
+//   - IOWait (float64): The percentage of the sample interval spent idle waiting for I/O to complete.
+//   - CPUs (float64): The number of CPUs the measurement covers, fractional for cgroup CPU quotas.
+type CPULoadSample struct {
+	Timestamp time.Time
+	Load      float64
+	Steal     float64
+	IOWait    float64
+	CPUs      float64
+}
+
+// LoadScale is the convention used to report CPU load percentages.
+type LoadScale int
+
+const (
+	// LoadScaleMachine reports load as a percentage of the total capacity of the measured CPUs, 0-100.
+	LoadScaleMachine LoadScale = iota
+	// LoadScaleCore reports load as a percentage of one core, like top, so a fully busy 4-CPU machine reports 400.
+	LoadScaleCore
 )
 
-// Starts the goroutine that measures the CPU load
-func StartCPUMeasuring() {
-	measurementMutex.Lock()
-	if isMeasuring {
+// cpuMeasureOptions holds the settings used by the CPU measurement loop.
+type cpuMeasureOptions struct {
+	sampleInterval time.Duration
+	window         int
+	retention      time.Duration
+	cgroup         bool
+	cpuset         bool
+	emaAlpha       float64
+	staleAfter     time.Duration
+	scale          LoadScale
+	idle           IdleDefinition
+	onResume       func(suspended time.Duration)
+}
+
+// CPUMeasureOption configures StartCPUMeasuring and NewCPUMonitor.
+type CPUMeasureOption func(*cpuMeasureOptions)
+
+// WithSampleInterval sets the duration of each CPU load measurement, default 100 ms.
+// Longer intervals reduce the overhead of measuring.
+func WithSampleInterval(interval time.Duration) CPUMeasureOption {
+	return func(o *cpuMeasureOptions) {
+		o.sampleInterval = interval
+	}
+}
+
+// WithWindow sets the number of measurements GetCPULoad averages over, default 10.
+// Larger windows give a smoother average.
+func WithWindow(samples int) CPUMeasureOption {
+	return func(o *cpuMeasureOptions) {
+		o.window = samples
+	}
+}
+
+// WithEMA makes GetCPULoad report an exponential moving average of the measurements instead of the mean of the
+// window. Each new measurement is weighted by alpha (0 < alpha <= 1) and the previous average by 1-alpha, so
+// higher values react faster to load changes. The window is still used by the other statistics.
+func WithEMA(alpha float64) CPUMeasureOption {
+	return func(o *cpuMeasureOptions) {
+		o.emaAlpha = alpha
+	}
+}
+
+// WithStaleAfter makes GetCPULoad, GetCPUSteal, GetCPUIOWait and GetCPULoadOver return ErrCPULoadStale when
+// no measurement has completed within d, e.g. because reading /proc/stat keeps failing. By default the
+// last averages are returned regardless of their age, use GetCPULastSampleTime to check it yourself.
+func WithStaleAfter(d time.Duration) CPUMeasureOption {
+	return func(o *cpuMeasureOptions) {
+		o.staleAfter = d
+	}
+}
+
+// WithLoadScale sets the convention of the reported load, steal and I/O wait percentages, default LoadScaleMachine.
+func WithLoadScale(scale LoadScale) CPUMeasureOption {
+	return func(o *cpuMeasureOptions) {
+		o.scale = scale
+	}
+}
+
+// WithIdleDefinition sets which CPU time counts as idle when calculating the load, default IdleWithIOWait.
+// Use IdleOnly to count I/O wait as busy on storage-heavy systems, or IdleWithIOWaitAndSteal to exclude time
+// stolen by the hypervisor from the load. Ignored with WithCgroupCPU, which measures usage directly.
+func WithIdleDefinition(idle IdleDefinition) CPUMeasureOption {
+	return func(o *cpuMeasureOptions) {
+		o.idle = idle
+	}
+}
+
+// WithResumeHandler sets a function that is called when the measurement loop detects that the system resumed
+// from suspend. The measurement spanning the suspend and all measurements taken before it are always discarded,
+// so the window refills with fresh data; fn additionally receives the time spent suspended. fn runs on its own goroutine.
+func WithResumeHandler(fn func(suspended time.Duration)) CPUMeasureOption {
+	return func(o *cpuMeasureOptions) {
+		o.onResume = fn
+	}
+}
+
+// WithRetention sets how long measurements are kept for GetCPULoadOver, default the duration of the window.
+// The retention never shortens the window used by GetCPULoad.
+func WithRetention(retention time.Duration) CPUMeasureOption {
+	return func(o *cpuMeasureOptions) {
+		o.retention = retention
+	}
+}
+
+// WithCgroupCPU measures the CPU usage of the cgroup of the calling process relative to its CPU quota
+// instead of host-wide usage from /proc/stat, which is what matters inside containers. Without a quota
+// the usage is relative to all online CPUs. Both cgroup v2 (cpu.stat and cpu.max) and cgroup v1
+// (cpuacct.usage and cpu.cfs_quota_us) are supported. Steal and I/O wait are not available per cgroup
+// and are reported as zero in this mode.
+func WithCgroupCPU() CPUMeasureOption {
+	return func(o *cpuMeasureOptions) {
+		o.cgroup = true
+	}
+}
+
+// WithAllowedCPUs measures the load of only the CPUs the calling process is allowed to run on, as restricted
+// by taskset or the cgroup cpuset, instead of all CPUs. The allowed CPUs are taken from Cpus_allowed_list in
+// /proc/self/status for every measurement, so changes to the affinity are picked up. This option is ignored
+// when combined with WithCgroupCPU, which already accounts for the CPUs of the cgroup.
+func WithAllowedCPUs() CPUMeasureOption {
+	return func(o *cpuMeasureOptions) {
+		o.cpuset = true
+	}
+}
+
+// newCPUMeasureOptions applies opts over the defaults. Invalid options are logged and replaced by their defaults.
+func newCPUMeasureOptions(opts []CPUMeasureOption) cpuMeasureOptions {
+	options := cpuMeasureOptions{
+		sampleInterval: defaultSampleInterval,
+		window:         defaultWindow,
+		idle:           IdleWithIOWait,
+	}
+	for _, opt := range opts {
+		opt(&options)
+	}
+	if options.sampleInterval <= 0 {
+		slog.Warn("Invalid CPU sample interval, using default", slog.Duration("sample_interval", options.sampleInterval))
+		options.sampleInterval = defaultSampleInterval
+	}
+	if options.window <= 0 {
+		slog.Warn("Invalid CPU measurement window, using default", slog.Int("window", options.window))
+		options.window = defaultWindow
+	}
+	if options.retention < 0 {
+		slog.Warn("Invalid CPU measurement retention, using default", slog.Duration("retention", options.retention))
+		options.retention = 0
+	}
+	if options.staleAfter < 0 {
+		slog.Warn("Invalid CPU load staleness threshold, disabling staleness detection", slog.Duration("stale_after", options.staleAfter))
+		options.staleAfter = 0
+	}
+	if options.scale != LoadScaleMachine && options.scale != LoadScaleCore {
+		slog.Warn("Invalid CPU load scale, using default", slog.Int("scale", int(options.scale)))
+		options.scale = LoadScaleMachine
+	}
+	if options.idle != IdleOnly && options.idle != IdleWithIOWait && options.idle != IdleWithIOWaitAndSteal {
+		slog.Warn("Invalid CPU idle definition, using default", slog.Int("idle", int(options.idle)))
+		options.idle = IdleWithIOWait
+	}
+	if options.emaAlpha < 0 || options.emaAlpha > 1 || math.IsNaN(options.emaAlpha) {
+		slog.Warn("Invalid CPU load EMA alpha, using simple moving average", slog.Float64("alpha", options.emaAlpha))
+		options.emaAlpha = 0
+	}
+
+	return options
+}
+
+// bufferSize returns the number of measurements to keep to cover both the window and the retention.
+func (o cpuMeasureOptions) bufferSize() int {
+	return max(o.window, int(math.Ceil(float64(o.retention)/float64(o.sampleInterval))))
+}
+
+// CPUMonitor measures the CPU load in the background and keeps the measurements of a sliding window.
+// Independent monitors can run side by side, e.g. with a short window for a UI and a long one for alerting.
+// The zero value is not usable, create monitors with NewCPUMonitor.
+type CPUMonitor struct {
+	// mu guards the running state and the generation of the measurement loop.
+	mu           sync.Mutex
+	running      bool
+	startedAt    time.Time
+	options      cpuMeasureOptions
+	generation   int
+	stopWatchdog chan struct{}
+
+	// samplesMu guards the measurements, which the watchdog reads without holding mu.
+	// The buffer holds the measurements of the window and the retention, newest last.
+	samplesMu      sync.Mutex
+	samples        *ringBuffer[CPULoadSample]
+	lastSampleTime time.Time
+	// ema is the exponential moving average of the load, valid once samples is not empty.
+	ema float64
+
+	// subsMu guards the subscribers to new measurements.
+	subsMu            sync.Mutex
+	subscribers       map[int]chan float64
+	sampleSubscribers map[int]chan CPULoadSample
+	nextSubID         int
+
+	restarts atomic.Int64
+}
+
+// defaultCPUMonitor is the monitor used by the package-level functions such as StartCPUMeasuring and GetCPULoad.
+var defaultCPUMonitor = NewCPUMonitor()
+
+// NewCPUMonitor creates a CPU monitor with the given options. The monitor does not measure until Start is called.
+// Invalid options are logged and replaced by their defaults.
+func NewCPUMonitor(opts ...CPUMeasureOption) *CPUMonitor {
+	return &CPUMonitor{options: newCPUMeasureOptions(opts)}
+}
+
+// Start starts the goroutine that measures the CPU load.
+// A watchdog restarts the measurement goroutine if it stops producing samples.
+func (m *CPUMonitor) Start() {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.start(m.options)
+}
+
+// start starts measuring with the given options. The caller must hold m.mu.
+func (m *CPUMonitor) start(options cpuMeasureOptions) {
+	if m.running {
 		slog.Warn("Unable to start CPU load measurement as it is already started")
 		return
 	}
-	isMeasuring = true
-	measurementMutex.Unlock()
+	m.running = true
+	m.startedAt = time.Now()
+	m.options = options
+
+	// Discard measurements from a previous run
+	m.samplesMu.Lock()
+	m.samples = newRingBuffer[CPULoadSample](options.bufferSize())
+	m.lastSampleTime = time.Time{}
+	m.ema = 0
+	m.samplesMu.Unlock()
+
+	m.startMeasureLoop()
+	m.stopWatchdog = make(chan struct{})
+	go m.supervise(m.stopWatchdog, options.sampleInterval)
+}
+
+// Stop stops the goroutine that measures the CPU load, along with its watchdog.
+// A measurement in progress is discarded. The monitor can be started again with Start.
+func (m *CPUMonitor) Stop() {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if !m.running {
+		slog.Warn("Unable to stop CPU load measurement as it is not started")
+		return
+	}
+	m.running = false
+
+	// Bumping the generation makes the running loop exit after its current measurement.
+	m.generation++
+	close(m.stopWatchdog)
+}
+
+// Restarts returns the number of times the watchdog has restarted the measurement goroutine.
+func (m *CPUMonitor) Restarts() int {
+	return int(m.restarts.Load())
+}
+
+// startMeasureLoop starts a new generation of the measurement goroutine, which makes any previous one exit.
+// The caller must hold m.mu.
+func (m *CPUMonitor) startMeasureLoop() {
+	m.generation++
+	go m.measureLoop(m.generation, m.options)
+}
+
+// measureLoop continuously measures the CPU load until a newer generation of the loop is started
+// or the measurement is stopped.
+func (m *CPUMonitor) measureLoop(generation int, options cpuMeasureOptions) {
+	defer func() {
+		if r := recover(); r != nil {
+			slog.Error("CPU load measurement goroutine panicked", slog.Any("panic", r))
+		}
+	}()
+
+	var sampler procStatSampler
+	defer sampler.close()
 
-	go func() {
-		for {
-			cpuLoad, err := doCPUMeasure()
-			if err != nil {
-				slog.Error("Failed to measure CPU load", slog.Any("error", err))
+	for {
+		m.mu.Lock()
+		superseded := generation != m.generation
+		m.mu.Unlock()
+		if superseded {
+			return
+		}
+
+		suspendedBefore, suspendErr := suspendedTime()
+
+		var sample CPULoadSample
+		var err error
+		switch {
+		case options.cgroup:
+			sample, err = doCgroupCPUMeasure(options.sampleInterval)
+		case options.cpuset:
+			sample, err = doCPUsetMeasure(&sampler, options.sampleInterval, options.idle)
+		default:
+			sample, err = doCPUMeasure(&sampler, options.sampleInterval, options.idle)
+		}
+		if err != nil {
+			slog.Error("Failed to measure CPU load", slog.Any("error", err))
+			// Reads such as those of the cgroup fail before the measurement sleeps, wait before retrying
+			// so a persistent failure does not spin
+			time.Sleep(options.sampleInterval)
+			continue
+		}
+
+		// The clocks only drift apart while the system is suspended
+		if suspendErr == nil {
+			if suspendedAfter, err := suspendedTime(); err == nil && suspendedAfter-suspendedBefore >= minSuspendGap {
+				m.discardSuspended(generation, suspendedAfter-suspendedBefore)
 				continue
 			}
+		}
 
-			// Update the averaged CPU load safely
-			loadMeasurementsMutex.Lock()
-			for i := len(loadMeasurements) - 1; i > 0; i-- {
-				loadMeasurements[i] = loadMeasurements[i-1]
-			}
-			loadMeasurements[0] = cpuLoad
-			loadMeasurementsMutex.Unlock()
+		if options.scale == LoadScaleCore {
+			sample = sample.perCore()
+		}
 
-			slog.Debug("Added new measurement", slog.Float64("new_measurement", cpuLoad), slog.Any("measurement_array", loadMeasurements))
+		// The loop may have been stopped or superseded during the measurement
+		m.mu.Lock()
+		if generation != m.generation {
+			m.mu.Unlock()
+			return
 		}
-	}()
+
+		// Update the averaged CPU load safely
+		m.samplesMu.Lock()
+		if m.samples.len() == 0 {
+			m.ema = sample.Load
+		} else {
+			m.ema = options.emaAlpha*sample.Load + (1-options.emaAlpha)*m.ema
+		}
+		m.lastSampleTime = time.Now()
+		sample.Timestamp = m.lastSampleTime
+		m.samples.push(sample)
+		slog.Debug("Added new measurement", slog.Float64("new_measurement", sample.Load), slog.Int("measurements", m.samples.len()))
+		m.samplesMu.Unlock()
+		m.mu.Unlock()
+
+		m.publish(sample)
+	}
+}
+
+// discardSuspended drops all measurements after the system was suspended during a measurement, since the
+// measurements taken before the suspend no longer describe the current load, and notifies the resume handler.
+func (m *CPUMonitor) discardSuspended(generation int, suspended time.Duration) {
+	m.mu.Lock()
+	if generation != m.generation {
+		m.mu.Unlock()
+		return
+	}
+	onResume := m.options.onResume
+
+	m.samplesMu.Lock()
+	m.samples.reset()
+	m.ema = 0
+	m.samplesMu.Unlock()
+	m.mu.Unlock()
+
+	slog.Info("System resumed from suspend, discarded CPU load measurements", slog.Duration("suspended", suspended))
+
+	if onResume != nil {
+		go onResume(suspended)
+	}
+}
+
+// supervise restarts the measurement goroutine when no sample has been produced for staleSampleAge,
+// or five sample intervals if that is longer.
+// Restarts are spaced with exponential backoff and the watchdog gives up after watchdogMaxRestarts consecutive
+// restarts without a sample in between.
+// The watchdog exits when stop is closed.
+func (m *CPUMonitor) supervise(stop <-chan struct{}, sampleInterval time.Duration) {
+	ticker := time.NewTicker(watchdogInterval)
+	defer ticker.Stop()
+
+	maxSampleAge := max(staleSampleAge, 5*sampleInterval)
+
+	// The loop is given maxSampleAge after each (re)start to produce its first sample.
+	lastStart := time.Now()
+	var nextAllowedRestart time.Time
+	restarts := 0
+
+	for {
+		select {
+		case <-stop:
+			return
+		case <-ticker.C:
+		}
+
+		m.samplesMu.Lock()
+		lastProgress := m.lastSampleTime
+		m.samplesMu.Unlock()
+		if lastStart.After(lastProgress) {
+			lastProgress = lastStart
+		} else if restarts > 0 {
+			// The restarted loop produced a sample, so a later stall starts over with the base backoff
+			slog.Info("CPU load measurement recovered after restart", slog.Int("restarts", restarts))
+			restarts = 0
+			nextAllowedRestart = time.Time{}
+		}
+
+		if time.Since(lastProgress) < maxSampleAge || time.Now().Before(nextAllowedRestart) {
+			continue
+		}
+
+		if restarts >= watchdogMaxRestarts {
+			slog.Error("CPU load measurement stopped producing samples, giving up after max restarts", slog.Int("restarts", restarts))
+			return
+		}
+
+		restarts++
+		m.restarts.Add(1)
+		backoff := min(watchdogBaseBackoff<<(restarts-1), watchdogMaxBackoff)
+		nextAllowedRestart = time.Now().Add(backoff)
+		lastStart = time.Now()
+
+		slog.Warn("CPU load measurement stopped producing samples, restarting", slog.Time("last_sample", lastProgress), slog.Int("restarts", restarts))
+
+		m.mu.Lock()
+		select {
+		case <-stop:
+		default:
+			m.startMeasureLoop()
+		}
+		m.mu.Unlock()
+	}
+}
+
+// Load retrieves the CPU load averaged over the measurement window, or the exponential moving average
+// of the measurements when the monitor is configured WithEMA.
+// Throws an error if the monitor has not started.
+func (m *CPUMonitor) Load() (float64, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if !m.running {
+		return 0.0, fmt.Errorf("CPU measurement loop has not started, start measurement before trying to read load")
+	}
+
+	if err := m.checkFresh(); err != nil {
+		return 0.0, err
+	}
+
+	if m.options.emaAlpha > 0 {
+		m.samplesMu.Lock()
+		defer m.samplesMu.Unlock()
+		return m.ema, nil
+	}
+
+	return m.windowAverage(func(sample CPULoadSample) float64 { return sample.Load }), nil
+}
+
+// Steal retrieves the percentage of CPU time stolen by the hypervisor, averaged over the measurement window.
+// Throws an error if the monitor has not started.
+func (m *CPUMonitor) Steal() (float64, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if !m.running {
+		return 0.0, fmt.Errorf("CPU measurement loop has not started, start measurement before trying to read steal")
+	}
+
+	if err := m.checkFresh(); err != nil {
+		return 0.0, err
+	}
+
+	return m.windowAverage(func(sample CPULoadSample) float64 { return sample.Steal }), nil
+}
+
+// IOWait retrieves the percentage of CPU time spent waiting for I/O, averaged over the measurement window.
+// Throws an error if the monitor has not started.
+func (m *CPUMonitor) IOWait() (float64, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if !m.running {
+		return 0.0, fmt.Errorf("CPU measurement loop has not started, start measurement before trying to read I/O wait")
+	}
+
+	if err := m.checkFresh(); err != nil {
+		return 0.0, err
+	}
+
+	return m.windowAverage(func(sample CPULoadSample) float64 { return sample.IOWait }), nil
+}
+
+// LastSampleTime returns the time the last measurement completed, the zero time if none has completed
+// since the monitor was started. Throws an error if the monitor has not started.
+func (m *CPUMonitor) LastSampleTime() (time.Time, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if !m.running {
+		return time.Time{}, fmt.Errorf("CPU measurement loop has not started, start measurement before trying to read the last sample time")
+	}
+
+	m.samplesMu.Lock()
+	defer m.samplesMu.Unlock()
+
+	return m.lastSampleTime, nil
+}
+
+// checkFresh returns ErrCPULoadStale if staleness detection is enabled and no measurement has completed
+// within the threshold, counting from the start of the monitor. The caller must hold m.mu.
+func (m *CPUMonitor) checkFresh() error {
+	if m.options.staleAfter == 0 {
+		return nil
+	}
+
+	m.samplesMu.Lock()
+	lastProgress := m.lastSampleTime
+	m.samplesMu.Unlock()
+	if lastProgress.IsZero() {
+		lastProgress = m.startedAt
+	}
+
+	if age := time.Since(lastProgress); age > m.options.staleAfter {
+		return fmt.Errorf("%w: no measurement completed for %s", ErrCPULoadStale, age.Round(time.Millisecond))
+	}
+
+	return nil
+}
+
+// SampleCount returns the number of measurements in the window, which is less than the window size while warming up.
+// Throws an error if the monitor has not started.
+func (m *CPUMonitor) SampleCount() (int, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if !m.running {
+		return 0, fmt.Errorf("CPU measurement loop has not started, start measurement before trying to read the sample count")
+	}
+
+	m.samplesMu.Lock()
+	defer m.samplesMu.Unlock()
+
+	return min(m.samples.len(), m.options.window), nil
+}
+
+// Ready reports whether the monitor is running and the window is filled with measurements.
+func (m *CPUMonitor) Ready() bool {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if !m.running {
+		return false
+	}
+
+	m.samplesMu.Lock()
+	defer m.samplesMu.Unlock()
+
+	return m.samples.len() >= m.options.window
+}
+
+// windowAverage averages a field of the measurements in the window, ignoring slots not yet filled.
+// Returns 0 before the first measurement. The caller must hold m.mu.
+func (m *CPUMonitor) windowAverage(field func(CPULoadSample) float64) float64 {
+	m.samplesMu.Lock()
+	defer m.samplesMu.Unlock()
+
+	n := min(m.samples.len(), m.options.window)
+	if n == 0 {
+		return 0
+	}
+
+	sum := 0.0
+	for i := range n {
+		sum += field(m.samples.newest(i))
+	}
+
+	return sum / float64(n)
+}
+
+// History retrieves the measurements in the window, oldest first.
+// Only completed measurements are returned, so the history is shorter than the window right after starting.
+// Throws an error if the monitor has not started.
+func (m *CPUMonitor) History() ([]CPULoadSample, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if !m.running {
+		return nil, fmt.Errorf("CPU measurement loop has not started, start measurement before trying to read load history")
+	}
+
+	m.samplesMu.Lock()
+	defer m.samplesMu.Unlock()
+
+	return m.samples.oldestFirst(m.options.window), nil
 }
 
-// GetCPULoad retrieves the CPU load averaged over 1 second
+// LoadOver retrieves the CPU load averaged over the measurements completed in the trailing duration d.
+// Measurements older than the retention (WithRetention) are not kept, so longer durations average
+// over the retained measurements only.
+// Throws an error if the monitor has not started or no measurement completed within d.
+func (m *CPUMonitor) LoadOver(d time.Duration) (float64, error) {
+	if d <= 0 {
+		return 0, fmt.Errorf("duration must be positive, got %s", d)
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if !m.running {
+		return 0.0, fmt.Errorf("CPU measurement loop has not started, start measurement before trying to read load")
+	}
+
+	if err := m.checkFresh(); err != nil {
+		return 0.0, err
+	}
+
+	m.samplesMu.Lock()
+	defer m.samplesMu.Unlock()
+
+	cutoff := time.Now().Add(-d)
+	sum := 0.0
+	count := 0
+	for i := range m.samples.len() {
+		sample := m.samples.newest(i)
+		if sample.Timestamp.Before(cutoff) {
+			break
+		}
+		sum += sample.Load
+		count++
+	}
+
+	if count == 0 {
+		return 0, fmt.Errorf("no CPU load measurement completed in the last %s", d)
+	}
+
+	return sum / float64(count), nil
+}
+
+// Starts the goroutine that measures the CPU load.
+// A watchdog restarts the measurement goroutine if it stops producing samples.
+// Invalid options are logged and replaced by their defaults.
+func StartCPUMeasuring(opts ...CPUMeasureOption) {
+	options := newCPUMeasureOptions(opts)
+
+	defaultCPUMonitor.mu.Lock()
+	defer defaultCPUMonitor.mu.Unlock()
+	defaultCPUMonitor.start(options)
+}
+
+// StopCPUMeasuring stops the goroutine that measures the CPU load, along with its watchdog.
+// A measurement in progress is discarded. The measurement can be started again with StartCPUMeasuring.
+func StopCPUMeasuring() {
+	defaultCPUMonitor.Stop()
+}
+
+// GetCPUMeasureRestarts returns the number of times the watchdog has restarted the CPU measurement goroutine.
+func GetCPUMeasureRestarts() int {
+	return defaultCPUMonitor.Restarts()
+}
+
+// GetCPULoad retrieves the CPU load averaged over the measurement window, 1 second by default,
+// or the exponential moving average when the measurement was started WithEMA.
+// Right after starting, the average covers only the completed measurements, see CPULoadReady.
 // Throws an error if the measurement loop has not started.
 func GetCPULoad() (float64, error) {
-	if !isMeasuring {
-		return 0.0, fmt.Errorf("CPU measurement loop has not started, start measurement before trying to read load")
+	return defaultCPUMonitor.Load()
+}
+
+// GetCPUSampleCount returns the number of measurements GetCPULoad currently averages over, which grows to the
+// window size after StartCPUMeasuring. Throws an error if the measurement loop has not started.
+func GetCPUSampleCount() (int, error) {
+	return defaultCPUMonitor.SampleCount()
+}
+
+// CPULoadReady reports whether the measurement loop is running and its window is filled, so GetCPULoad
+// averages over the full window.
+func CPULoadReady() bool {
+	return defaultCPUMonitor.Ready()
+}
+
+// GetCPULastSampleTime returns the time the last CPU load measurement completed, the zero time if none has
+// completed yet. Compare it with the sample interval to detect a measurement loop that stopped producing samples.
+// Throws an error if the measurement loop has not started.
+func GetCPULastSampleTime() (time.Time, error) {
+	return defaultCPUMonitor.LastSampleTime()
+}
+
+// GetCPULoadOver retrieves the CPU load averaged over the measurements completed in the trailing duration d,
+// e.g. GetCPULoadOver(5 * time.Second). Start the measurement with WithRetention to keep measurements for
+// longer than the window.
+// Throws an error if the measurement loop has not started or no measurement completed within d.
+func GetCPULoadOver(d time.Duration) (float64, error) {
+	return defaultCPUMonitor.LoadOver(d)
+}
+
+// GetCPUSteal retrieves the percentage of CPU time stolen by the hypervisor, averaged over the measurement window.
+// High steal on a virtual machine indicates a noisy neighbour competing for the physical CPUs.
+// Throws an error if the measurement loop has not started.
+func GetCPUSteal() (float64, error) {
+	return defaultCPUMonitor.Steal()
+}
+
+// GetCPUIOWait retrieves the percentage of CPU time spent waiting for I/O, averaged over the measurement window.
+// I/O wait counts as idle in GetCPULoad, so high I/O wait with low load points to a storage bottleneck.
+// Throws an error if the measurement loop has not started.
+func GetCPUIOWait() (float64, error) {
+	return defaultCPUMonitor.IOWait()
+}
+
+// GetCPULoadHistory retrieves the measurements in the window, oldest first, for rendering or custom statistics.
+// Only completed measurements are returned, so the history is shorter than the window right after starting.
+// Throws an error if the measurement loop has not started.
+func GetCPULoadHistory() ([]CPULoadSample, error) {
+	return defaultCPUMonitor.History()
+}
+
+// procStatSampler takes the two /proc/stat snapshots of each measurement through one open procStatReader and
+// reuses the parsed snapshots between measurements. /proc/stat is reopened after a failed read.
+type procStatSampler struct {
+	reader *procStatReader
+	before procStat
+	after  procStat
+}
+
+// snapshots reads /proc/stat, waits for the interval and reads it again.
+// The returned snapshots are only valid until the next call.
+func (s *procStatSampler) snapshots(interval time.Duration) (before, after *procStat, err error) {
+	if s.reader == nil {
+		if s.reader, err = newProcStatReader(); err != nil {
+			return nil, nil, err
+		}
 	}
 
-	avgLoad := 0.0
+	if err := s.reader.read(&s.before); err != nil {
+		s.close()
+		return nil, nil, err
+	}
 
-	measurementMutex.Lock()
-	defer measurementMutex.Unlock()
+	time.Sleep(interval)
 
-	for i := 0; i < len(loadMeasurements); i++ {
-		avgLoad += loadMeasurements[i]
+	if err := s.reader.read(&s.after); err != nil {
+		s.close()
+		return nil, nil, err
 	}
 
-	avgLoad = avgLoad / float64(len(loadMeasurements))
+	return &s.before, &s.after, nil
+}
 
-	return avgLoad, nil
+// close closes /proc/stat if it is open.
+func (s *procStatSampler) close() {
+	if s.reader != nil {
+		s.reader.close()
+		s.reader = nil
+	}
 }
 
-// Does one blocking measurement of CPU load over a period of 100 ms
-func doCPUMeasure() (float64, error) {
-	// Helper function to read CPU stats from /proc/stat
-	readCPUStats := func() (totalTime, idleTime float64, err error) {
-		file, err := os.Open("/proc/stat")
-		if err != nil {
-			slog.Error("Failed to read process info", slog.String("path", "/proc/stat"), slog.Any("error", err))
-			return 0, 0, err
-		}
-		defer file.Close()
-
-		scanner := bufio.NewScanner(file)
-
-		for scanner.Scan() {
-			line := scanner.Text()
-
-			if strings.HasPrefix(line, "cpu ") {
-				fields := strings.Fields(line)
-				slog.Debug("Found CPU line", slog.String("cpu_statistics", line))
-
-				// The CPU statistics line in /proc/stat has these fields:
-				// 0: Prefix ("cpu" for aggregate statistics or "cpuN" for individual cores)
-
-				// Core CPU statistics fields
-				// 1: User       - Time spent in user mode
-				// 2: Nice       - Time spent in user mode with low priority (nice)
-				// 3: System     - Time spent in system mode
-				// 4: Idle       - Time spent in the idle task
-
-				// Added in Linux 2.5.41:
-				// 5: IOWait     - Time spent waiting for I/O to complete
-				// 6: IRQ        - Time spent servicing hardware interrupts
-				// 7: SoftIRQ    - Time spent servicing software interrupts
-
-				// Added in Linux 2.6.11:
-				// 8: Steal      - Time spent in other operating systems when running in a virtualized environment
-
-				// Added in Linux 2.6.24:
-				// 9: Guest      - Time spent running a virtual CPU for guest operating systems
-				// 10: GuestNice - Time spent running a low-priority virtual CPU for guest operating systems
-
-				// Validate the number of fields
-				if len(fields) > 11 || len(fields) < 5 {
-					return 0, 0, fmt.Errorf("unexpected number of CPU fields in /proc/stat, cpu line: %s", line)
-				}
-
-				for i := 1; i < len(fields); i++ {
-					value, err := strconv.ParseFloat(fields[i], 64)
-					if err != nil {
-						return 0, 0, fmt.Errorf("failed to parse CPU field %d: %w", i, err)
-					}
-					totalTime += value
-					if i == 4 || i == 5 { // Idle and IOWait
-						idleTime += value
-					}
-				}
-				break
-			}
+// Does one blocking measurement of CPU load over the given interval
+// The timestamp of the returned sample is left unset.
+func doCPUMeasure(sampler *procStatSampler, interval time.Duration, idle IdleDefinition) (CPULoadSample, error) {
+	stat1, stat2, err := sampler.snapshots(interval)
+	if err != nil {
+		return CPULoadSample{}, err
+	}
+
+	sample, err := cpuTimesSample(stat1.cpu, stat2.cpu, idle)
+	if err != nil {
+		return CPULoadSample{}, err
+	}
+	sample.CPUs = float64(len(stat2.cores))
+
+	return sample, nil
+}
+
+// Does one blocking measurement of the load of the CPUs the calling process may run on over the given interval.
+// The allowed CPUs are read at the end of the interval, CPUs that were offline at either snapshot are skipped.
+// The timestamp of the returned sample is left unset.
+func doCPUsetMeasure(sampler *procStatSampler, interval time.Duration, idle IdleDefinition) (CPULoadSample, error) {
+	stat1, stat2, err := sampler.snapshots(interval)
+	if err != nil {
+		return CPULoadSample{}, err
+	}
+
+	allowed, err := allowedCPUs()
+	if err != nil {
+		return CPULoadSample{}, err
+	}
+
+	var times1, times2 cpuTimes
+	cpus := 0
+	for _, cpu := range allowed {
+		core1, ok1 := stat1.cores[cpu]
+		core2, ok2 := stat2.cores[cpu]
+		if !ok1 || !ok2 {
+			continue
+		}
+		for i := range times1 {
+			times1[i] += core1[i]
+			times2[i] += core2[i]
 		}
+		cpus++
+	}
+
+	sample, err := cpuTimesSample(times1, times2, idle)
+	if err != nil {
+		return CPULoadSample{}, err
+	}
+	sample.CPUs = float64(cpus)
+
+	return sample, nil
+}
+
+// perCore converts a sample from a percentage of the measured CPUs to a percentage of one core.
+func (s CPULoadSample) perCore() CPULoadSample {
+	s.Load *= s.CPUs
+	s.Steal *= s.CPUs
+	s.IOWait *= s.CPUs
+	return s
+}
+
+// cpuTimesSample calculates the load, steal and I/O wait between two snapshots of CPU time counters,
+// counting the time matching the idle definition as idle.
+func cpuTimesSample(times1, times2 cpuTimes, idle IdleDefinition) (CPULoadSample, error) {
+	cpuLoad, err := calculateCPULoad(times1.total(), times1.idleAs(idle), times2.total(), times2.idleAs(idle))
+	if err != nil {
+		return CPULoadSample{}, err
+	}
+	slog.Debug("Calculated CPU load over duration", slog.Float64("cpu_load_percent", cpuLoad))
+
+	totalDiff := times2.total() - times1.total()
+	sample := CPULoadSample{
+		Load:   cpuLoad,
+		Steal:  100 * (times2[cpuSteal] - times1[cpuSteal]) / totalDiff,
+		IOWait: 100 * (times2[cpuIOWait] - times1[cpuIOWait]) / totalDiff,
+	}
 
-		// Handle scanning errors
-		if err := scanner.Err(); err != nil {
-			slog.Error("Failed to scan /proc/stat", slog.Any("error", err))
-			return 0, 0, err
+	return sample, nil
+}
+
+// Does one blocking measurement of the CPU usage of the cgroup of the calling process over the given interval,
+// relative to its CPU quota. The timestamp of the returned sample is left unset.
+func doCgroupCPUMeasure(interval time.Duration) (CPULoadSample, error) {
+	cpu1, err := readCgroupCPU()
+	if err != nil {
+		return CPULoadSample{}, err
+	}
+	start := time.Now()
+
+	time.Sleep(interval)
+
+	cpu2, err := readCgroupCPU()
+	if err != nil {
+		return CPULoadSample{}, err
+	}
+	elapsed := time.Since(start)
+
+	limitCPUs := cpu2.limitCPUs
+	if limitCPUs == 0 {
+		cpus, err := onlineCPUs()
+		if err != nil {
+			return CPULoadSample{}, err
 		}
+		limitCPUs = float64(len(cpus))
+	}
+	if limitCPUs == 0 || elapsed <= 0 {
+		return CPULoadSample{}, fmt.Errorf("divide by zero: no CPU capacity available to the cgroup")
+	}
+
+	cpuLoad := 100 * (cpu2.usage - cpu1.usage).Seconds() / (elapsed.Seconds() * limitCPUs)
+	slog.Debug("Calculated cgroup CPU load over duration", slog.Float64("cpu_load_percent", cpuLoad), slog.Float64("limit_cpus", limitCPUs))
+
+	return CPULoadSample{Load: cpuLoad, CPUs: limitCPUs}, nil
+}
 
-		return totalTime, idleTime, nil
+// MeasureCPULoad performs a single blocking measurement of the host CPU load over d.
+// It does not require the background measurement loop to be started and returns ctx's error if ctx is
+// cancelled before the measurement completes.
+func MeasureCPULoad(ctx context.Context, d time.Duration) (float64, error) {
+	if d <= 0 {
+		return 0, fmt.Errorf("duration must be positive, got %s", d)
 	}
 
-	// Read the first snapshot
 	totalTime1, idleTime1, err := readCPUStats()
 	if err != nil {
 		return 0, err
 	}
 
-	// 100 ms between two measurements
-	time.Sleep(time.Millisecond * 100)
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+
+	select {
+	case <-ctx.Done():
+		return 0, ctx.Err()
+	case <-timer.C:
+	}
 
-	// Read the second snapshot
 	totalTime2, idleTime2, err := readCPUStats()
 	if err != nil {
 		return 0, err
 	}
 
+	cpuLoad, err := calculateCPULoad(totalTime1, idleTime1, totalTime2, idleTime2)
+	if err != nil {
+		return 0, err
+	}
+	slog.Debug("Measured CPU load", slog.Duration("duration", d), slog.Float64("cpu_load_percent", cpuLoad))
+
+	return cpuLoad, nil
+}
+
+// MeasureCPUSamples performs n sequential CPU load measurements, each over the given interval, and returns all of them.
+// It does not require the background measurement loop to be started.
+func MeasureCPUSamples(n int, interval time.Duration) ([]float64, error) {
+	return MeasureCPUSamplesContext(context.Background(), n, interval)
+}
+
+// MeasureCPUSamplesContext is like MeasureCPUSamples but aborts early when ctx is cancelled,
+// returning the samples collected so far together with the context error.
+func MeasureCPUSamplesContext(ctx context.Context, n int, interval time.Duration) ([]float64, error) {
+	if n <= 0 {
+		return nil, fmt.Errorf("number of samples must be positive, got %d", n)
+	}
+	if interval <= 0 {
+		return nil, fmt.Errorf("interval must be positive, got %s", interval)
+	}
+
+	totalTime, idleTime, err := readCPUStats()
+	if err != nil {
+		return nil, err
+	}
+
+	timer := time.NewTimer(interval)
+	defer timer.Stop()
+
+	samples := make([]float64, 0, n)
+	for len(samples) < n {
+		select {
+		case <-ctx.Done():
+			return samples, ctx.Err()
+		case <-timer.C:
+		}
+
+		// Each snapshot ends one sample and starts the next, so n samples need n+1 snapshots.
+		nextTotalTime, nextIdleTime, err := readCPUStats()
+		if err != nil {
+			return samples, err
+		}
+		timer.Reset(interval)
+
+		cpuLoad, err := calculateCPULoad(totalTime, idleTime, nextTotalTime, nextIdleTime)
+		if err != nil {
+			return samples, err
+		}
+		samples = append(samples, cpuLoad)
+
+		totalTime, idleTime = nextTotalTime, nextIdleTime
+	}
+
+	slog.Debug("Measured CPU samples", slog.Any("samples", samples))
+
+	return samples, nil
+}
+
+// calculateCPULoad calculates the CPU load percentage between two snapshots of CPU time.
+func calculateCPULoad(totalTime1, idleTime1, totalTime2, idleTime2 float64) (float64, error) {
 	// Calculate the differences
 	totalDiff := totalTime2 - totalTime1
 	idleDiff := idleTime2 - idleTime1
@@ -163,8 +1013,140 @@ func doCPUMeasure() (float64, error) {
 		return 0, fmt.Errorf("no CPU activity detected during the interval")
 	}
 
-	cpuLoad := 100 * (totalDiff - idleDiff) / totalDiff
-	slog.Debug("Calculated CPU load over duration", slog.Float64("cpu_load_percent", cpuLoad))
+	return 100 * (totalDiff - idleDiff) / totalDiff, nil
+}
 
-	return cpuLoad, nil
+// readCPUStats reads the aggregate CPU statistics from /proc/stat and returns the total and idle time.
+func readCPUStats() (totalTime, idleTime float64, err error) {
+	stat, err := readProcStat()
+	if err != nil {
+		return 0, 0, err
+	}
+
+	return stat.cpu.total(), stat.cpu.idle(), nil
+}
+
+// GetPerCoreCPULoad measures the load of each CPU core over 100 ms, indexed by CPU number.
+// Each value is a percentage of that single core, 0-100, regardless of the LoadScale of the measurement loop.
+// This call blocks for the duration of the measurement and does not require the background loop.
+// Cores that are offline at the start or end of the measurement report a load of 0.
+func GetPerCoreCPULoad() ([]float64, error) {
+	stat1, err := readProcStat()
+	if err != nil {
+		return nil, err
+	}
+
+	time.Sleep(time.Millisecond * 100)
+
+	stat2, err := readProcStat()
+	if err != nil {
+		return nil, err
+	}
+
+	// Cores may be hot-unplugged or plugged during the interval, so size the result to cover both snapshots.
+	numCores := 0
+	for cpu := range stat1.cores {
+		numCores = max(numCores, cpu+1)
+	}
+	for cpu := range stat2.cores {
+		numCores = max(numCores, cpu+1)
+	}
+
+	loads := make([]float64, numCores)
+	for cpu, times2 := range stat2.cores {
+		times1, ok := stat1.cores[cpu]
+		if !ok {
+			continue
+		}
+		cpuLoad, err := calculateCPULoad(times1.total(), times1.idle(), times2.total(), times2.idle())
+		if err != nil {
+			// An idle tickless core may not have accumulated any time during the interval.
+			continue
+		}
+		loads[cpu] = cpuLoad
+	}
+
+	slog.Debug("Calculated per-core CPU load", slog.Any("cpu_load_percent", loads))
+
+	return loads, nil
+}
+
+// suspendedTime returns the total time the system has spent suspended since boot, which is the difference
+// between CLOCK_BOOTTIME and CLOCK_MONOTONIC as only the former advances during suspend.
+func suspendedTime() (time.Duration, error) {
+	var boottime, monotonic syscall.Timespec
+	if _, _, errno := syscall.Syscall(syscall.SYS_CLOCK_GETTIME, clockBoottime, uintptr(unsafe.Pointer(&boottime)), 0); errno != 0 {
+		return 0, fmt.Errorf("failed to read CLOCK_BOOTTIME: %w", errno)
+	}
+	if _, _, errno := syscall.Syscall(syscall.SYS_CLOCK_GETTIME, clockMonotonic, uintptr(unsafe.Pointer(&monotonic)), 0); errno != 0 {
+		return 0, fmt.Errorf("failed to read CLOCK_MONOTONIC: %w", errno)
+	}
+
+	return time.Duration(boottime.Nano() - monotonic.Nano()), nil
+}
+
+// PhysicalCoreLoad represents the load of a physical core.
+// Fields:
+//   - PhysicalCore (PhysicalCore): The physical core and its logical CPUs.
+//   - Load (float64): The load in percent of the capacity of all threads of the core.
+//   - ThreadLoads ([]float64): The load of each thread in percent, in the order of Threads.
+type PhysicalCoreLoad struct {
+	PhysicalCore
+	Load        float64
+	ThreadLoads []float64
+}
+
+// GetPhysicalCoreLoad measures the load of each physical core over 100 ms, ordered by its first CPU.
+// On SMT systems a core whose hyperthreads are both busy reports 100, and one with a single busy thread 50;
+// capacity planning needs this physical view as hyperthreads share the execution units of their core.
+// This call blocks for the duration of the measurement and does not require the background loop.
+func GetPhysicalCoreLoad() ([]PhysicalCoreLoad, error) {
+	cores, err := GetPhysicalCores()
+	if err != nil {
+		return nil, err
+	}
+
+	stat1, err := readProcStat()
+	if err != nil {
+		return nil, err
+	}
+
+	time.Sleep(time.Millisecond * 100)
+
+	stat2, err := readProcStat()
+	if err != nil {
+		return nil, err
+	}
+
+	loads := make([]PhysicalCoreLoad, 0, len(cores))
+	for _, core := range cores {
+		coreLoad := PhysicalCoreLoad{PhysicalCore: core, ThreadLoads: make([]float64, len(core.Threads))}
+
+		var times1, times2 cpuTimes
+		for i, cpu := range core.Threads {
+			thread1, ok1 := stat1.cores[cpu]
+			thread2, ok2 := stat2.cores[cpu]
+			if !ok1 || !ok2 {
+				// The thread went offline during the measurement
+				continue
+			}
+			for j := range times1 {
+				times1[j] += thread1[j]
+				times2[j] += thread2[j]
+			}
+			if threadLoad, err := calculateCPULoad(thread1.total(), thread1.idle(), thread2.total(), thread2.idle()); err == nil {
+				coreLoad.ThreadLoads[i] = threadLoad
+			}
+		}
+
+		// An idle tickless core may not have accumulated any time during the interval.
+		if load, err := calculateCPULoad(times1.total(), times1.idle(), times2.total(), times2.idle()); err == nil {
+			coreLoad.Load = load
+		}
+		loads = append(loads, coreLoad)
+	}
+
+	slog.Debug("Calculated physical core load", slog.Any("physical_core_loads", loads))
+
+	return loads, nil
 }
@@ -0,0 +1,75 @@
+package resourceutil
+
+import (
+	"sync"
+	"testing"
+	"time"
+)
+
+// TestCPUMonitorConcurrentAccess exercises all entry points of one monitor at the same time,
+// run it with -race to detect unsynchronized access to the measurements.
+func TestCPUMonitorConcurrentAccess(t *testing.T) {
+	m := NewCPUMonitor(WithSampleInterval(time.Millisecond), WithWindow(5), WithRetention(50*time.Millisecond))
+	m.Start()
+
+	done := make(chan struct{})
+	var wg sync.WaitGroup
+	run := func(fn func()) {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for {
+				select {
+				case <-done:
+					return
+				default:
+					fn()
+				}
+			}
+		}()
+	}
+
+	// Errors are expected while the monitor is briefly stopped
+	run(func() { _, _ = m.Load() })
+	run(func() { _, _ = m.History() })
+	run(func() { _, _ = m.LoadOver(20 * time.Millisecond) })
+	run(func() {
+		updates, cancel := m.Subscribe(1)
+		select {
+		case <-updates:
+		case <-time.After(5 * time.Millisecond):
+		}
+		cancel()
+	})
+	run(func() {
+		m.Stop()
+		m.Start()
+		time.Sleep(5 * time.Millisecond)
+	})
+
+	time.Sleep(300 * time.Millisecond)
+	close(done)
+	wg.Wait()
+
+	// The monitor must still produce measurements after the restarts
+	deadline := time.Now().Add(2 * time.Second)
+	for {
+		history, err := m.History()
+		if err != nil {
+			t.Fatalf("History() error = %v", err)
+		}
+		if len(history) > 0 {
+			for i := 1; i < len(history); i++ {
+				if history[i].Timestamp.Before(history[i-1].Timestamp) {
+					t.Errorf("History() is not ordered oldest first at index %d", i)
+				}
+			}
+			break
+		}
+		if time.Now().After(deadline) {
+			t.Fatal("no measurement completed after restarting the monitor")
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+	m.Stop()
+}
@@ -0,0 +1,311 @@
+package resourceutil
+
+import (
+	"errors"
+	"fmt"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"slices"
+	"strconv"
+	"strings"
+	"syscall"
+)
+
+// CPUFrequency represents the frequency of a CPU core.
+// Fields:
+//   - CPU (int): The CPU number.
+//   - CurrentMHz (float64): The current frequency in MHz.
+//   - MinMHz (float64): The minimum frequency the core may be scaled to in MHz, 0 if unknown.
+//   - MaxMHz (float64): The maximum frequency the core may be scaled to in MHz, 0 if unknown.
+type CPUFrequency struct {
+	CPU        int
+	CurrentMHz float64
+	MinMHz     float64
+	MaxMHz     float64
+}
+
+// GetCPUFrequencies retrieves the current, minimum and maximum frequency of each online CPU core, ordered by CPU
+// number. Cores that go offline while reading are omitted.
+//
+// Frequencies are read from the cpufreq scaling_cur_freq, scaling_min_freq and scaling_max_freq attributes.
+// When cpufreq is unavailable, e.g. in many virtual machines, the current frequency is read from the
+// "cpu MHz" field of /proc/cpuinfo instead and the minimum and maximum are left at zero.
+func GetCPUFrequencies() ([]CPUFrequency, error) {
+	cpus, err := onlineCPUs()
+	if err != nil {
+		return nil, err
+	}
+	slices.Sort(cpus)
+
+	frequencies := make([]CPUFrequency, 0, len(cpus))
+	for _, cpu := range cpus {
+		dir := filepath.Join(cpuSysDir, fmt.Sprintf("cpu%d", cpu), "cpufreq")
+
+		current, err := intFromFile(filepath.Join(dir, "scaling_cur_freq"))
+		if err != nil {
+			// Systems without cpufreq have no frequency attributes, and cores going offline
+			// fail with EBUSY or ENODEV
+			if !errors.Is(err, os.ErrNotExist) {
+				slog.Debug("Skipping CPU without readable frequency", slog.Int("cpu", cpu), slog.Any("error", err))
+			}
+			continue
+		}
+
+		// All values are reported in kHz
+		frequency := CPUFrequency{CPU: cpu, CurrentMHz: float64(current) / 1000}
+		if minFreq, err := intFromFile(filepath.Join(dir, "scaling_min_freq")); err == nil {
+			frequency.MinMHz = float64(minFreq) / 1000
+		}
+		if maxFreq, err := intFromFile(filepath.Join(dir, "scaling_max_freq")); err == nil {
+			frequency.MaxMHz = float64(maxFreq) / 1000
+		}
+		frequencies = append(frequencies, frequency)
+	}
+
+	if len(frequencies) == 0 {
+		return cpuInfoFrequencies()
+	}
+
+	slog.Debug("Got CPU frequencies", slog.Any("cpu_frequencies", frequencies))
+
+	return frequencies, nil
+}
+
+// cpuInfoFrequencies reads the current frequency of each core from the "cpu MHz" field of /proc/cpuinfo.
+func cpuInfoFrequencies() ([]CPUFrequency, error) {
+	processors, err := readCPUInfo()
+	if err != nil {
+		return nil, err
+	}
+
+	var frequencies []CPUFrequency
+	for _, processor := range processors {
+		mhzStr, ok := processor["cpu MHz"]
+		if !ok {
+			continue
+		}
+		cpu, err := strconv.Atoi(processor["processor"])
+		if err != nil {
+			return nil, fmt.Errorf("failed to parse processor number in /proc/cpuinfo: %w", err)
+		}
+		mhz, err := strconv.ParseFloat(mhzStr, 64)
+		if err != nil {
+			return nil, fmt.Errorf("failed to parse frequency of CPU %d in /proc/cpuinfo: %w", cpu, err)
+		}
+		frequencies = append(frequencies, CPUFrequency{CPU: cpu, CurrentMHz: mhz})
+	}
+
+	if len(frequencies) == 0 {
+		return nil, fmt.Errorf("no CPU frequency information found in cpufreq or /proc/cpuinfo")
+	}
+
+	slog.Debug("Got CPU frequencies from /proc/cpuinfo", slog.Any("cpu_frequencies", frequencies))
+
+	return frequencies, nil
+}
+
+// cpufreqDir is the sysfs directory where the kernel exposes cpufreq policies.
+var cpufreqDir = filepath.Join(cpuSysDir, "cpufreq")
+
+// ErrCPUFreqUnsupported is returned when the kernel exposes no cpufreq policies, e.g. in most virtual machines.
+var ErrCPUFreqUnsupported = errors.New("cpufreq not available")
+
+// CPUFreqPolicy represents a cpufreq policy, which controls the frequency of a group of CPUs.
+// Fields:
+//   - Policy (int): The policy number, from policyN.
+//   - CPUs ([]int): The online CPUs controlled by the policy.
+//   - Driver (string): The scaling driver, e.g. "intel_pstate" or "acpi-cpufreq".
+//   - Governor (string): The current scaling governor, e.g. "powersave" or "performance".
+//   - AvailableGovernors ([]string): The governors that can be selected for the policy.
+type CPUFreqPolicy struct {
+	Policy             int
+	CPUs               []int
+	Driver             string
+	Governor           string
+	AvailableGovernors []string
+}
+
+// GetCPUFreqPolicies retrieves the scaling driver and governors of each cpufreq policy, ordered by policy number.
+// Inactive policies, whose CPUs are all offline, are skipped. Returns ErrCPUFreqUnsupported if the kernel exposes no cpufreq policies.
+func GetCPUFreqPolicies() ([]CPUFreqPolicy, error) {
+	policyDirs, err := filepath.Glob(filepath.Join(cpufreqDir, "policy[0-9]*"))
+	if err != nil {
+		return nil, err
+	}
+	if len(policyDirs) == 0 {
+		return nil, ErrCPUFreqUnsupported
+	}
+
+	policies := make([]CPUFreqPolicy, 0, len(policyDirs))
+	for _, dir := range policyDirs {
+		number, err := strconv.Atoi(strings.TrimPrefix(filepath.Base(dir), "policy"))
+		if err != nil {
+			continue
+		}
+
+		policy := CPUFreqPolicy{Policy: number}
+
+		cpuList, err := stringFromFile(filepath.Join(dir, "affected_cpus"))
+		if err != nil {
+			return nil, fmt.Errorf("failed to get CPUs of cpufreq policy %d: %w", number, err)
+		}
+		// affected_cpus is a space separated list rather than a range list
+		policy.CPUs, err = parseCPUList(strings.Join(strings.Fields(cpuList), ","))
+		if err != nil {
+			return nil, fmt.Errorf("failed to get CPUs of cpufreq policy %d: %w", number, err)
+		}
+		if len(policy.CPUs) == 0 {
+			// All CPUs of the policy are offline
+			slog.Debug("Skipping inactive cpufreq policy", slog.Int("policy", number))
+			continue
+		}
+
+		policy.Driver, err = stringFromFile(filepath.Join(dir, "scaling_driver"))
+		if err != nil {
+			return nil, fmt.Errorf("failed to get scaling driver of cpufreq policy %d: %w", number, err)
+		}
+		policy.Governor, err = stringFromFile(filepath.Join(dir, "scaling_governor"))
+		if errors.Is(err, syscall.EBUSY) {
+			// The policy became inactive as its last CPU went offline
+			slog.Debug("Skipping inactive cpufreq policy", slog.Int("policy", number))
+			continue
+		}
+		if err != nil {
+			return nil, fmt.Errorf("failed to get governor of cpufreq policy %d: %w", number, err)
+		}
+		if available, err := stringFromFile(filepath.Join(dir, "scaling_available_governors")); err == nil {
+			policy.AvailableGovernors = strings.Fields(available)
+		}
+
+		policies = append(policies, policy)
+	}
+
+	slices.SortFunc(policies, func(a, b CPUFreqPolicy) int {
+		return a.Policy - b.Policy
+	})
+
+	slog.Debug("Got cpufreq policies", slog.Any("cpufreq_policies", policies))
+
+	return policies, nil
+}
+
+// SetCPUGovernor sets the scaling governor of every cpufreq policy, e.g. to "powersave" or "performance".
+// The governor must be available for every active policy, policies whose CPUs are all offline are left
+// unchanged. This requires root privileges.
+// Returns ErrCPUFreqUnsupported if the kernel exposes no cpufreq policies.
+func SetCPUGovernor(governor string) error {
+	if governor == "" {
+		return fmt.Errorf("governor cannot be empty")
+	}
+
+	policies, err := GetCPUFreqPolicies()
+	if err != nil {
+		return err
+	}
+
+	// Validate up front so a typo does not leave policies with mixed governors
+	for _, policy := range policies {
+		if len(policy.AvailableGovernors) > 0 && !slices.Contains(policy.AvailableGovernors, governor) {
+			return fmt.Errorf("governor %s not available for cpufreq policy %d, available: %v", governor, policy.Policy, policy.AvailableGovernors)
+		}
+	}
+
+	for _, policy := range policies {
+		path := filepath.Join(cpufreqDir, fmt.Sprintf("policy%d", policy.Policy), "scaling_governor")
+		if err := os.WriteFile(path, []byte(governor), 0o644); err != nil {
+			return fmt.Errorf("failed to set governor of cpufreq policy %d: %w", policy.Policy, err)
+		}
+	}
+
+	slog.Info("Set CPU governor", slog.String("governor", governor), slog.Int("policies", len(policies)))
+
+	return nil
+}
+
+// ErrBoostUnsupported is returned when neither intel_pstate nor cpufreq expose a boost (turbo) control.
+var ErrBoostUnsupported = errors.New("CPU boost control not available")
+
+// Paths of the boost controls, intel_pstate reports the inverse of the cpufreq control.
+var (
+	intelPstateNoTurboPath = filepath.Join(cpuSysDir, "intel_pstate", "no_turbo")
+	cpufreqBoostPath       = filepath.Join(cpufreqDir, "boost")
+)
+
+// BoostState represents the boost (turbo) state of the CPUs.
+// Fields:
+//   - Enabled (bool): Whether the CPUs may run above their base frequency.
+//   - Control (string): The interface controlling boost, "intel_pstate" (no_turbo) or "cpufreq" (boost).
+//   - BaseMHz (float64): The base (non-boost) frequency in MHz, 0 if the driver does not report it.
+//   - MaxMHz (float64): The highest frequency any CPU can reach in MHz, the boost frequency when boost is enabled.
+//   - CurrentMHz (float64): The highest current frequency of any CPU in MHz.
+type BoostState struct {
+	Enabled    bool
+	Control    string
+	BaseMHz    float64
+	MaxMHz     float64
+	CurrentMHz float64
+}
+
+// GetCPUBoost retrieves whether boost (turbo) is enabled along with the base, maximum and current frequencies,
+// e.g. to correlate boost behaviour with thermal throttling. Returns ErrBoostUnsupported if no boost control exists.
+func GetCPUBoost() (BoostState, error) {
+	var state BoostState
+	if noTurbo, err := intFromFile(intelPstateNoTurboPath); err == nil {
+		state.Enabled = noTurbo == 0
+		state.Control = "intel_pstate"
+	} else if boost, err := intFromFile(cpufreqBoostPath); err == nil {
+		state.Enabled = boost == 1
+		state.Control = "cpufreq"
+	} else {
+		return BoostState{}, ErrBoostUnsupported
+	}
+
+	// All values are reported in kHz
+	policyDirs, err := filepath.Glob(filepath.Join(cpufreqDir, "policy[0-9]*"))
+	if err != nil {
+		return BoostState{}, err
+	}
+	for _, dir := range policyDirs {
+		if base, err := intFromFile(filepath.Join(dir, "base_frequency")); err == nil {
+			state.BaseMHz = max(state.BaseMHz, float64(base)/1000)
+		}
+		if maxFreq, err := intFromFile(filepath.Join(dir, "cpuinfo_max_freq")); err == nil {
+			state.MaxMHz = max(state.MaxMHz, float64(maxFreq)/1000)
+		}
+		if current, err := intFromFile(filepath.Join(dir, "scaling_cur_freq")); err == nil {
+			state.CurrentMHz = max(state.CurrentMHz, float64(current)/1000)
+		}
+	}
+
+	slog.Debug("Got CPU boost state", slog.Any("boost_state", state))
+
+	return state, nil
+}
+
+// SetCPUBoost enables or disables boost (turbo) for all CPUs. This requires root privileges.
+// Returns ErrBoostUnsupported if no boost control exists.
+func SetCPUBoost(enabled bool) error {
+	var path, value string
+	if _, err := os.Stat(intelPstateNoTurboPath); err == nil {
+		path, value = intelPstateNoTurboPath, "1"
+		if enabled {
+			value = "0"
+		}
+	} else if _, err := os.Stat(cpufreqBoostPath); err == nil {
+		path, value = cpufreqBoostPath, "0"
+		if enabled {
+			value = "1"
+		}
+	} else {
+		return ErrBoostUnsupported
+	}
+
+	if err := os.WriteFile(path, []byte(value), 0o644); err != nil {
+		return fmt.Errorf("failed to set CPU boost: %w", err)
+	}
+
+	slog.Info("Set CPU boost", slog.Bool("enabled", enabled), slog.String("path", path))
+
+	return nil
+}
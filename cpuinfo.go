@@ -0,0 +1,218 @@
+package resourceutil
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"slices"
+	"strconv"
+	"strings"
+)
+
+// readCPUInfo reads /proc/cpuinfo and returns the key-value pairs of each processor block in file order.
+func readCPUInfo() ([]map[string]string, error) {
+	file, err := os.Open("/proc/cpuinfo")
+	if err != nil {
+		slog.Error("Failed to read CPU info", slog.String("path", "/proc/cpuinfo"), slog.Any("error", err))
+		return nil, err
+	}
+	defer file.Close()
+
+	var processors []map[string]string
+	var current map[string]string
+	scanner := bufio.NewScanner(file)
+
+	for scanner.Scan() {
+		line := scanner.Text()
+
+		// Blocks are separated by blank lines
+		if strings.TrimSpace(line) == "" {
+			if current != nil {
+				processors = append(processors, current)
+				current = nil
+			}
+			continue
+		}
+
+		key, value, ok := strings.Cut(line, ":")
+		if !ok {
+			continue
+		}
+		if current == nil {
+			current = make(map[string]string)
+		}
+		current[strings.TrimSpace(key)] = strings.TrimSpace(value)
+	}
+	if current != nil {
+		processors = append(processors, current)
+	}
+
+	if err := scanner.Err(); err != nil {
+		slog.Error("Failed to scan /proc/cpuinfo", slog.Any("error", err))
+		return nil, err
+	}
+
+	return processors, nil
+}
+
+// CPUCache represents a CPU cache level.
+// Fields:
+//   - Level (int): The cache level, e.g. 1 for L1.
+//   - Type (string): The cache type, "Data", "Instruction" or "Unified".
+//   - SizeKB (int): The size of the cache in kilobytes.
+//   - SharedCPUs ([]int): The logical CPUs sharing this cache instance.
+type CPUCache struct {
+	Level      int
+	Type       string
+	SizeKB     int
+	SharedCPUs []int
+}
+
+// CPUInfo represents the model and features of the CPU.
+// Fields:
+//   - Vendor (string): The vendor ID, e.g. "GenuineIntel", or the implementer code on ARM.
+//   - ModelName (string): The human readable model name, empty if not reported.
+//   - Family (string): The CPU family, empty if not reported.
+//   - Model (string): The model number, or the part number on ARM.
+//   - Stepping (string): The stepping, or the revision on ARM.
+//   - Sockets (int): The number of physical packages.
+//   - PhysicalCores (int): The number of physical cores.
+//   - LogicalCPUs (int): The number of online logical CPUs.
+//   - Flags ([]string): The CPU feature flags ("flags" on x86, "Features" on ARM).
+//   - Caches ([]CPUCache): The caches of the first online CPU, ordered by level.
+type CPUInfo struct {
+	Vendor        string
+	ModelName     string
+	Family        string
+	Model         string
+	Stepping      string
+	Sockets       int
+	PhysicalCores int
+	LogicalCPUs   int
+	Flags         []string
+	Caches        []CPUCache
+}
+
+// GetCPUInfo retrieves the model, vendor, core counts, feature flags and cache sizes of the CPU,
+// similar to the output of lscpu. Hybrid systems are described by their first processor.
+func GetCPUInfo() (CPUInfo, error) {
+	processors, err := readCPUInfo()
+	if err != nil {
+		return CPUInfo{}, err
+	}
+	if len(processors) == 0 {
+		return CPUInfo{}, fmt.Errorf("no processors found in /proc/cpuinfo")
+	}
+
+	// The first block describes the first processor. Fall back to the x86 name for each field
+	// when the ARM one is missing.
+	first := processors[0]
+	field := func(keys ...string) string {
+		for _, key := range keys {
+			if value, ok := first[key]; ok {
+				return value
+			}
+		}
+		return ""
+	}
+
+	info := CPUInfo{
+		Vendor:    field("vendor_id", "CPU implementer"),
+		ModelName: field("model name", "Processor"),
+		Family:    field("cpu family", "CPU architecture"),
+		Model:     field("model", "CPU part"),
+		Stepping:  field("stepping", "CPU revision"),
+		Flags:     strings.Fields(field("flags", "Features")),
+	}
+
+	topology, err := GetCPUTopology()
+	if err != nil {
+		return CPUInfo{}, err
+	}
+	info.Sockets = topology.Sockets
+	info.PhysicalCores = topology.Cores
+	info.LogicalCPUs = topology.Threads
+
+	if len(topology.CPUs) > 0 {
+		info.Caches, err = readCPUCaches(topology.CPUs[0].CPU)
+		if err != nil {
+			return CPUInfo{}, err
+		}
+	}
+
+	slog.Debug("Got CPU info", slog.String("model_name", info.ModelName), slog.Int("logical_cpus", info.LogicalCPUs))
+
+	return info, nil
+}
+
+// readCPUCaches reads the caches of a CPU from /sys/devices/system/cpu/cpuN/cache, ordered by level.
+// Returns an empty slice if the kernel does not expose cache information.
+func readCPUCaches(cpu int) ([]CPUCache, error) {
+	dir := filepath.Join(cpuSysDir, fmt.Sprintf("cpu%d", cpu), "cache")
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		if errors.Is(err, os.ErrNotExist) {
+			return []CPUCache{}, nil
+		}
+		return nil, fmt.Errorf("failed to list caches of CPU %d: %w", cpu, err)
+	}
+
+	caches := []CPUCache{}
+	for _, entry := range entries {
+		if !strings.HasPrefix(entry.Name(), "index") {
+			continue
+		}
+		indexDir := filepath.Join(dir, entry.Name())
+
+		level, err := intFromFile(filepath.Join(indexDir, "level"))
+		if err != nil {
+			return nil, fmt.Errorf("failed to get cache level of CPU %d: %w", cpu, err)
+		}
+		cacheType, err := stringFromFile(filepath.Join(indexDir, "type"))
+		if err != nil {
+			return nil, fmt.Errorf("failed to get cache type of CPU %d: %w", cpu, err)
+		}
+		sizeStr, err := stringFromFile(filepath.Join(indexDir, "size"))
+		if err != nil {
+			return nil, fmt.Errorf("failed to get cache size of CPU %d: %w", cpu, err)
+		}
+		size, err := parseCacheSize(sizeStr)
+		if err != nil {
+			return nil, fmt.Errorf("failed to get cache size of CPU %d: %w", cpu, err)
+		}
+
+		cache := CPUCache{Level: level, Type: cacheType, SizeKB: size}
+		if sharedList, err := stringFromFile(filepath.Join(indexDir, "shared_cpu_list")); err == nil {
+			cache.SharedCPUs, _ = parseCPUList(sharedList)
+		}
+		caches = append(caches, cache)
+	}
+
+	slices.SortStableFunc(caches, func(a, b CPUCache) int {
+		return a.Level - b.Level
+	})
+
+	return caches, nil
+}
+
+// parseCacheSize parses a cache size such as "48K" or "2M" into kilobytes.
+func parseCacheSize(size string) (int, error) {
+	multiplier := 1
+	switch {
+	case strings.HasSuffix(size, "K"):
+		size = strings.TrimSuffix(size, "K")
+	case strings.HasSuffix(size, "M"):
+		size = strings.TrimSuffix(size, "M")
+		multiplier = 1024
+	}
+
+	value, err := strconv.Atoi(size)
+	if err != nil {
+		return 0, fmt.Errorf("failed to parse cache size %s: %w", size, err)
+	}
+
+	return value * multiplier, nil
+}
@@ -0,0 +1,143 @@
+package resourceutil
+
+import (
+	"log/slog"
+	"time"
+)
+
+// Subscribe returns a channel that receives the load of each new measurement as it completes, along with
+// a function that cancels the subscription and closes the channel. Measurements are dropped for a subscriber
+// whose buffer is full, so a slow consumer never stalls the measurement loop. The subscription survives
+// Stop and Start, it only receives nothing while the monitor is stopped.
+func (m *CPUMonitor) Subscribe(buffer int) (<-chan float64, func()) {
+	if buffer < 0 {
+		slog.Warn("Invalid CPU load subscription buffer, using unbuffered", slog.Int("buffer", buffer))
+		buffer = 0
+	}
+	updates := make(chan float64, buffer)
+
+	m.subsMu.Lock()
+	if m.subscribers == nil {
+		m.subscribers = make(map[int]chan float64)
+	}
+	id := m.nextSubID
+	m.nextSubID++
+	m.subscribers[id] = updates
+	m.subsMu.Unlock()
+
+	cancel := func() {
+		m.subsMu.Lock()
+		defer m.subsMu.Unlock()
+		if _, ok := m.subscribers[id]; !ok {
+			return
+		}
+		delete(m.subscribers, id)
+		close(updates)
+	}
+
+	return updates, cancel
+}
+
+// subscribeSamples is like Subscribe but receives whole measurements, including their timestamps.
+func (m *CPUMonitor) subscribeSamples(buffer int) (<-chan CPULoadSample, func()) {
+	updates := make(chan CPULoadSample, buffer)
+
+	m.subsMu.Lock()
+	if m.sampleSubscribers == nil {
+		m.sampleSubscribers = make(map[int]chan CPULoadSample)
+	}
+	id := m.nextSubID
+	m.nextSubID++
+	m.sampleSubscribers[id] = updates
+	m.subsMu.Unlock()
+
+	cancel := func() {
+		m.subsMu.Lock()
+		defer m.subsMu.Unlock()
+		if _, ok := m.sampleSubscribers[id]; !ok {
+			return
+		}
+		delete(m.sampleSubscribers, id)
+		close(updates)
+	}
+
+	return updates, cancel
+}
+
+// publish sends a new measurement to all subscribers without blocking.
+func (m *CPUMonitor) publish(sample CPULoadSample) {
+	m.subsMu.Lock()
+	defer m.subsMu.Unlock()
+
+	for id, updates := range m.subscribers {
+		select {
+		case updates <- sample.Load:
+		default:
+			slog.Debug("Dropped CPU load update as subscriber is not keeping up", slog.Int("subscriber", id))
+		}
+	}
+	for id, updates := range m.sampleSubscribers {
+		select {
+		case updates <- sample:
+		default:
+			slog.Debug("Dropped CPU load update as subscriber is not keeping up", slog.Int("subscriber", id))
+		}
+	}
+}
+
+// SubscribeCPULoad returns a channel that receives the load of each new measurement of the background
+// measurement loop as it completes, along with a function that cancels the subscription and closes the channel.
+// Measurements are dropped while the buffer is full. The channel stays open across StopCPUMeasuring.
+func SubscribeCPULoad(buffer int) (<-chan float64, func()) {
+	return defaultCPUMonitor.Subscribe(buffer)
+}
+
+// OnLoadAbove calls fn once the load of every measurement has stayed above threshold for at least the sustained
+// duration, e.g. to shed load when the CPU stays above 90% for 30 seconds. fn receives the load of the measurement
+// that completed the sustained period and is called again only after the load has dropped to or below threshold
+// and risen above it for another sustained period. The sustained period is timed by the measurements and starts
+// over after a gap of more than two sample intervals, e.g. while the monitor was stopped.
+// fn runs on its own goroutine, one call at a time. The returned function cancels the callback.
+func (m *CPUMonitor) OnLoadAbove(threshold float64, sustained time.Duration, fn func(load float64)) func() {
+	updates, cancel := m.subscribeSamples(1)
+
+	go func() {
+		var aboveSince, previous time.Time
+		fired := false
+
+		for sample := range updates {
+			m.mu.Lock()
+			maxGap := 2 * m.options.sampleInterval
+			m.mu.Unlock()
+			if !previous.IsZero() && sample.Timestamp.Sub(previous) > maxGap {
+				// Measurements were missed, so the load in between is unknown
+				aboveSince = time.Time{}
+			}
+			previous = sample.Timestamp
+
+			if sample.Load <= threshold {
+				aboveSince = time.Time{}
+				fired = false
+				continue
+			}
+
+			if aboveSince.IsZero() {
+				aboveSince = sample.Timestamp
+			}
+			if !fired && sample.Timestamp.Sub(aboveSince) >= sustained {
+				fired = true
+				slog.Debug("CPU load stayed above threshold", slog.Float64("threshold", threshold), slog.Duration("sustained", sustained), slog.Float64("cpu_load_percent", sample.Load))
+				fn(sample.Load)
+			}
+		}
+	}()
+
+	return cancel
+}
+
+// OnCPULoadAbove calls fn once the CPU load measured by the background measurement loop has stayed above
+// threshold for at least the sustained duration. It fires once per excursion above the threshold and runs fn
+// on its own goroutine. The returned function cancels the callback.
+func OnCPULoadAbove(threshold float64, sustained time.Duration, fn func(load float64)) func() {
+	return defaultCPUMonitor.OnLoadAbove(threshold, sustained, fn)
+}
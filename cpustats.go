@@ -0,0 +1,90 @@
+package resourceutil
+
+import (
+	"fmt"
+	"math"
+	"slices"
+)
+
+// CPULoadStats represents statistics of the CPU load over the measurement window.
+// Fields:
+//   - Samples (int): The number of completed measurements the statistics are computed over.
+//   - Mean (float64): The mean load in percent.
+//   - Min (float64): The lowest load in percent.
+//   - Max (float64): The highest load in percent.
+//   - StdDev (float64): The population standard deviation of the load in percentage points.
+//   - Percentiles (map[float64]float64): The requested percentiles of the load, keyed by percentile (e.g. 95 for p95).
+type CPULoadStats struct {
+	Samples     int
+	Mean        float64
+	Min         float64
+	Max         float64
+	StdDev      float64
+	Percentiles map[float64]float64
+}
+
+// LoadStats computes the mean, min, max, standard deviation and the given percentiles (0-100) of the
+// load over the completed measurements in the window. Percentiles are linearly interpolated between samples.
+// Throws an error if the monitor has not started, no measurement has completed yet or a percentile is out of range.
+func (m *CPUMonitor) LoadStats(percentiles ...float64) (CPULoadStats, error) {
+	for _, p := range percentiles {
+		if p < 0 || p > 100 || math.IsNaN(p) {
+			return CPULoadStats{}, fmt.Errorf("percentile must be between 0 and 100, got %v", p)
+		}
+	}
+
+	history, err := m.History()
+	if err != nil {
+		return CPULoadStats{}, err
+	}
+	if len(history) == 0 {
+		return CPULoadStats{}, fmt.Errorf("no CPU load measurement has completed yet")
+	}
+
+	loads := make([]float64, len(history))
+	for i, sample := range history {
+		loads[i] = sample.Load
+	}
+	slices.Sort(loads)
+
+	stats := CPULoadStats{
+		Samples:     len(loads),
+		Min:         loads[0],
+		Max:         loads[len(loads)-1],
+		Percentiles: make(map[float64]float64, len(percentiles)),
+	}
+
+	for _, load := range loads {
+		stats.Mean += load
+	}
+	stats.Mean /= float64(len(loads))
+
+	variance := 0.0
+	for _, load := range loads {
+		variance += (load - stats.Mean) * (load - stats.Mean)
+	}
+	stats.StdDev = math.Sqrt(variance / float64(len(loads)))
+
+	for _, p := range percentiles {
+		stats.Percentiles[p] = percentile(loads, p)
+	}
+
+	return stats, nil
+}
+
+// percentile returns the p-th percentile of the sorted values, linearly interpolated between the closest ranks.
+func percentile(sorted []float64, p float64) float64 {
+	rank := p / 100 * float64(len(sorted)-1)
+	lower := int(math.Floor(rank))
+	upper := int(math.Ceil(rank))
+
+	return sorted[lower] + (rank-float64(lower))*(sorted[upper]-sorted[lower])
+}
+
+// GetCPULoadStats computes the mean, min, max, standard deviation and the given percentiles (0-100) of the
+// CPU load over the completed measurements in the window. Short spikes hidden by the average show up in Max
+// and the high percentiles.
+// Throws an error if the measurement loop has not started or no measurement has completed yet.
+func GetCPULoadStats(percentiles ...float64) (CPULoadStats, error) {
+	return defaultCPUMonitor.LoadStats(percentiles...)
+}
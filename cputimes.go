@@ -0,0 +1,86 @@
+package resourceutil
+
+import (
+	"fmt"
+	"log/slog"
+	"time"
+)
+
+// CPUTimeValues holds a value for each kind of CPU time reported in /proc/stat.
+// Guest and GuestNice are also included in User and Nice by the kernel.
+type CPUTimeValues struct {
+	User      float64
+	Nice      float64
+	System    float64
+	Idle      float64
+	IOWait    float64
+	IRQ       float64
+	SoftIRQ   float64
+	Steal     float64
+	Guest     float64
+	GuestNice float64
+}
+
+// CPUTimes represents the breakdown of the aggregate CPU time of all cores.
+// Fields:
+//   - Jiffies (CPUTimeValues): The cumulative time in each state since boot in USER_HZ (jiffies).
+//   - Percent (CPUTimeValues): The percentage of time spent in each state over the measured interval. All fields but
+//     Guest and GuestNice sum to 100, those two are the part of User and Nice spent running guests.
+type CPUTimes struct {
+	Jiffies CPUTimeValues
+	Percent CPUTimeValues
+}
+
+// GetCPUTimes measures the breakdown of CPU time into user, nice, system, idle, iowait, irq, softirq,
+// steal and guest time over the given interval. This call blocks for the duration of the interval.
+func GetCPUTimes(interval time.Duration) (CPUTimes, error) {
+	if interval <= 0 {
+		return CPUTimes{}, fmt.Errorf("interval must be positive, got %s", interval)
+	}
+
+	stat1, err := readProcStat()
+	if err != nil {
+		return CPUTimes{}, err
+	}
+
+	time.Sleep(interval)
+
+	stat2, err := readProcStat()
+	if err != nil {
+		return CPUTimes{}, err
+	}
+
+	totalDiff := stat2.cpu.total() - stat1.cpu.total()
+	if totalDiff == 0 {
+		return CPUTimes{}, fmt.Errorf("no CPU activity detected during the interval")
+	}
+
+	var percent cpuTimes
+	for i := range percent {
+		percent[i] = 100 * (stat2.cpu[i] - stat1.cpu[i]) / totalDiff
+	}
+
+	times := CPUTimes{
+		Jiffies: stat2.cpu.values(),
+		Percent: percent.values(),
+	}
+	slog.Debug("Got CPU times", slog.Any("cpu_times", times))
+
+	return times, nil
+}
+
+// values converts the time counters into a CPUTimeValues.
+func (t cpuTimes) values() CPUTimeValues {
+	return CPUTimeValues{
+		User:      t[cpuUser],
+		Nice:      t[cpuNice],
+		System:    t[cpuSystem],
+		Idle:      t[cpuIdle],
+		IOWait:    t[cpuIOWait],
+		IRQ:       t[cpuIRQ],
+		SoftIRQ:   t[cpuSoftIRQ],
+		Steal:     t[cpuSteal],
+		Guest:     t[cpuGuest],
+		GuestNice: t[cpuGuestNice],
+	}
+}
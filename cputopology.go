@@ -0,0 +1,343 @@
+package resourceutil
+
+import (
+	"errors"
+	"fmt"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"slices"
+	"strconv"
+	"strings"
+)
+
+// cpuSysDir is the sysfs directory where the kernel exposes CPUs.
+const cpuSysDir = "/sys/devices/system/cpu"
+
+// ErrCPUCapacityUnsupported is returned when the CPUs do not expose cpu_capacity, as is the case on most homogeneous systems.
+var ErrCPUCapacityUnsupported = errors.New("CPU capacity not exposed by the kernel")
+
+// listCPUs returns the IDs of the CPUs that have a cpuN directory in sysfs, in no particular order.
+func listCPUs() ([]int, error) {
+	entries, err := os.ReadDir(cpuSysDir)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list CPUs in %s: %w", cpuSysDir, err)
+	}
+
+	var cpus []int
+	for _, entry := range entries {
+		idStr, ok := strings.CutPrefix(entry.Name(), "cpu")
+		if !ok {
+			continue
+		}
+		id, err := strconv.Atoi(idStr)
+		if err != nil {
+			// Not a CPU directory, e.g. cpufreq or cpuidle
+			continue
+		}
+		cpus = append(cpus, id)
+	}
+
+	return cpus, nil
+}
+
+// onlineCPUs returns the IDs of the CPUs that are currently online.
+func onlineCPUs() ([]int, error) {
+	online, err := stringFromFile(filepath.Join(cpuSysDir, "online"))
+	if err != nil {
+		return nil, fmt.Errorf("failed to get online CPUs: %w", err)
+	}
+
+	cpus, err := parseCPUList(online)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get online CPUs: %w", err)
+	}
+
+	return cpus, nil
+}
+
+// allowedCPUs returns the IDs of the CPUs the calling process is allowed to run on, from Cpus_allowed_list in
+// /proc/self/status, which reflects both the scheduler affinity (taskset) and the cgroup cpuset.
+func allowedCPUs() ([]int, error) {
+	data, err := os.ReadFile("/proc/self/status")
+	if err != nil {
+		return nil, fmt.Errorf("failed to read /proc/self/status: %w", err)
+	}
+
+	for _, line := range strings.Split(string(data), "\n") {
+		list, ok := strings.CutPrefix(line, "Cpus_allowed_list:")
+		if !ok {
+			continue
+		}
+		cpus, err := parseCPUList(list)
+		if err != nil {
+			return nil, fmt.Errorf("failed to get allowed CPUs: %w", err)
+		}
+		return cpus, nil
+	}
+
+	return nil, fmt.Errorf("Cpus_allowed_list not found in /proc/self/status")
+}
+
+// CPUCounts represents the number of CPUs in each hotplug state.
+// Fields:
+//   - Online (int): The number of CPUs currently online and schedulable.
+//   - Offline (int): The number of CPUs that are present or possible but not online.
+//   - Present (int): The number of CPUs physically present in the system.
+//   - Possible (int): The number of CPUs the kernel has allocated resources for, including ones that could be hotplugged.
+type CPUCounts struct {
+	Online   int
+	Offline  int
+	Present  int
+	Possible int
+}
+
+// GetCPUCounts retrieves the number of online, offline, present and possible CPUs from /sys/devices/system/cpu.
+// The counts change when cores are hot-unplugged, e.g. for power saving.
+func GetCPUCounts() (CPUCounts, error) {
+	var counts CPUCounts
+	for _, state := range []struct {
+		file  string
+		count *int
+	}{
+		{"online", &counts.Online},
+		{"offline", &counts.Offline},
+		{"present", &counts.Present},
+		{"possible", &counts.Possible},
+	} {
+		list, err := stringFromFile(filepath.Join(cpuSysDir, state.file))
+		if err != nil {
+			return CPUCounts{}, fmt.Errorf("failed to get %s CPUs: %w", state.file, err)
+		}
+		cpus, err := parseCPUList(list)
+		if err != nil {
+			return CPUCounts{}, fmt.Errorf("failed to get %s CPUs: %w", state.file, err)
+		}
+		*state.count = len(cpus)
+	}
+
+	slog.Debug("Got CPU counts", slog.Any("cpu_counts", counts))
+
+	return counts, nil
+}
+
+// GetCPUCapacities retrieves the relative capacity of each CPU, keyed by CPU ID.
+//
+// Capacities are normalized by the kernel so that the most powerful CPU has a capacity of 1024,
+// which makes them useful for placing heavy work on big/performance cores of heterogeneous systems.
+// Returns ErrCPUCapacityUnsupported if no CPU exposes cpu_capacity.
+func GetCPUCapacities() (map[int]int, error) {
+	cpus, err := listCPUs()
+	if err != nil {
+		return nil, err
+	}
+
+	capacities := make(map[int]int, len(cpus))
+	for _, cpu := range cpus {
+		capacity, err := intFromFile(filepath.Join(cpuSysDir, fmt.Sprintf("cpu%d", cpu), "cpu_capacity"))
+		if err != nil {
+			if errors.Is(err, os.ErrNotExist) {
+				continue
+			}
+			return nil, fmt.Errorf("failed to get capacity for CPU %d: %w", cpu, err)
+		}
+		capacities[cpu] = capacity
+	}
+
+	if len(capacities) == 0 {
+		return nil, ErrCPUCapacityUnsupported
+	}
+
+	slog.Debug("Got CPU capacities", slog.Any("cpu_capacities", capacities))
+
+	return capacities, nil
+}
+
+// parseCPUList parses a kernel CPU list such as "0-3,8,10-11" into the individual CPU IDs.
+// An empty list results in an empty slice.
+func parseCPUList(list string) ([]int, error) {
+	cpus := []int{}
+	list = strings.TrimSpace(list)
+	if list == "" {
+		return cpus, nil
+	}
+
+	for _, part := range strings.Split(list, ",") {
+		first, last, isRange := strings.Cut(part, "-")
+
+		start, err := strconv.Atoi(first)
+		if err != nil {
+			return nil, fmt.Errorf("failed to parse CPU list %s: %w", list, err)
+		}
+
+		end := start
+		if isRange {
+			end, err = strconv.Atoi(last)
+			if err != nil {
+				return nil, fmt.Errorf("failed to parse CPU list %s: %w", list, err)
+			}
+			if end < start {
+				return nil, fmt.Errorf("failed to parse CPU list %s: invalid range %s", list, part)
+			}
+		}
+
+		for cpu := start; cpu <= end; cpu++ {
+			cpus = append(cpus, cpu)
+		}
+	}
+
+	return cpus, nil
+}
+
+// LogicalCPU represents the placement of a logical CPU in the CPU topology.
+// Fields:
+//   - CPU (int): The logical CPU number.
+//   - Socket (int): The physical package (socket) ID.
+//   - Core (int): The core ID, which may repeat across dies and clusters of a socket.
+//   - Node (int): The NUMA node the CPU belongs to, 0 on systems without NUMA.
+//   - Siblings ([]int): The logical CPUs sharing the same physical core (hyperthreads), including this one.
+type LogicalCPU struct {
+	CPU      int
+	Socket   int
+	Core     int
+	Node     int
+	Siblings []int
+}
+
+// CPUTopology represents the layout of the online CPUs of the system.
+// Fields:
+//   - Sockets (int): The number of physical packages.
+//   - Cores (int): The number of physical cores.
+//   - Threads (int): The number of logical CPUs.
+//   - NUMANodes (int): The number of NUMA nodes with CPUs.
+//   - CPUs ([]LogicalCPU): The placement of each logical CPU, ordered by CPU number.
+type CPUTopology struct {
+	Sockets   int
+	Cores     int
+	Threads   int
+	NUMANodes int
+	CPUs      []LogicalCPU
+}
+
+// GetCPUTopology retrieves the sockets, physical cores, hyperthread siblings and NUMA node assignment
+// of the online CPUs from /sys/devices/system/cpu/cpu*/topology. CPUs that go offline while reading are omitted.
+func GetCPUTopology() (CPUTopology, error) {
+	cpus, err := onlineCPUs()
+	if err != nil {
+		return CPUTopology{}, err
+	}
+	slices.Sort(cpus)
+
+	topology := CPUTopology{CPUs: make([]LogicalCPU, 0, len(cpus))}
+	sockets := make(map[int]bool)
+	cores := make(map[[2]int]bool)
+	nodes := make(map[int]bool)
+
+	for _, cpu := range cpus {
+		dir := filepath.Join(cpuSysDir, fmt.Sprintf("cpu%d", cpu))
+
+		socket, err := intFromFile(filepath.Join(dir, "topology", "physical_package_id"))
+		if errors.Is(err, os.ErrNotExist) {
+			// The CPU went offline after the online list was read
+			continue
+		}
+		if err != nil {
+			return CPUTopology{}, fmt.Errorf("failed to get socket of CPU %d: %w", cpu, err)
+		}
+		core, err := intFromFile(filepath.Join(dir, "topology", "core_id"))
+		if err != nil {
+			return CPUTopology{}, fmt.Errorf("failed to get core of CPU %d: %w", cpu, err)
+		}
+		siblingList, err := stringFromFile(filepath.Join(dir, "topology", "thread_siblings_list"))
+		if err != nil {
+			return CPUTopology{}, fmt.Errorf("failed to get siblings of CPU %d: %w", cpu, err)
+		}
+		siblings, err := parseCPUList(siblingList)
+		if err != nil {
+			return CPUTopology{}, fmt.Errorf("failed to get siblings of CPU %d: %w", cpu, err)
+		}
+
+		node, err := cpuNode(dir)
+		if err != nil {
+			return CPUTopology{}, fmt.Errorf("failed to get NUMA node of CPU %d: %w", cpu, err)
+		}
+
+		topology.CPUs = append(topology.CPUs, LogicalCPU{
+			CPU:      cpu,
+			Socket:   socket,
+			Core:     core,
+			Node:     node,
+			Siblings: siblings,
+		})
+		sockets[socket] = true
+		cores[[2]int{socket, core}] = true
+		nodes[node] = true
+	}
+
+	topology.Sockets = len(sockets)
+	topology.Cores = len(cores)
+	topology.Threads = len(topology.CPUs)
+	topology.NUMANodes = len(nodes)
+
+	slog.Debug("Got CPU topology", slog.Any("cpu_topology", topology))
+
+	return topology, nil
+}
+
+// PhysicalCore represents a physical core and the logical CPUs (hyperthreads) running on it.
+// Fields:
+//   - Socket (int): The physical package (socket) ID.
+//   - Core (int): The core ID, which may repeat across dies and clusters of a socket.
+//   - Threads ([]int): The online logical CPUs of the core, ordered by CPU number.
+type PhysicalCore struct {
+	Socket  int
+	Core    int
+	Threads []int
+}
+
+// GetPhysicalCores groups the online logical CPUs by the physical core they run on, as given by their
+// thread_siblings_list, ordered by their first CPU. On systems without SMT every core has a single thread.
+func GetPhysicalCores() ([]PhysicalCore, error) {
+	topology, err := GetCPUTopology()
+	if err != nil {
+		return nil, err
+	}
+
+	var cores []PhysicalCore
+	index := make(map[string]int)
+	// The CPUs of the topology are ordered by CPU number, so the cores and their threads are too
+	for _, cpu := range topology.CPUs {
+		// Core IDs repeat across dies and clusters on some platforms, the sibling list identifies the core
+		siblings := slices.Sorted(slices.Values(cpu.Siblings))
+		key := fmt.Sprint(siblings)
+		i, ok := index[key]
+		if !ok {
+			i = len(cores)
+			index[key] = i
+			cores = append(cores, PhysicalCore{Socket: cpu.Socket, Core: cpu.Core})
+		}
+		cores[i].Threads = append(cores[i].Threads, cpu.CPU)
+	}
+
+	return cores, nil
+}
+
+// cpuNode returns the NUMA node of the CPU at the sysfs directory from its nodeN link, 0 if it has none.
+func cpuNode(dir string) (int, error) {
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return 0, err
+	}
+
+	for _, entry := range entries {
+		idStr, ok := strings.CutPrefix(entry.Name(), "node")
+		if !ok {
+			continue
+		}
+		if node, err := strconv.Atoi(idStr); err == nil {
+			return node, nil
+		}
+	}
+
+	return 0, nil
+}
@@ -0,0 +1,40 @@
+package resourceutil
+
+import (
+	"errors"
+	"fmt"
+	"log/slog"
+	"os"
+	"path/filepath"
+)
+
+// ErrVulnerabilitiesUnsupported is returned when the kernel does not report CPU vulnerabilities, as before Linux 4.15.
+var ErrVulnerabilitiesUnsupported = errors.New("CPU vulnerabilities not reported by the kernel")
+
+// GetCPUVulnerabilities retrieves the mitigation status of each known CPU vulnerability from
+// /sys/devices/system/cpu/vulnerabilities, keyed by vulnerability (e.g. "spectre_v2", "meltdown").
+// Statuses are reported as by the kernel, e.g. "Not affected", "Vulnerable" or "Mitigation: PTI".
+// Returns ErrVulnerabilitiesUnsupported if the directory does not exist.
+func GetCPUVulnerabilities() (map[string]string, error) {
+	dir := filepath.Join(cpuSysDir, "vulnerabilities")
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		if errors.Is(err, os.ErrNotExist) {
+			return nil, ErrVulnerabilitiesUnsupported
+		}
+		return nil, fmt.Errorf("failed to list CPU vulnerabilities in %s: %w", dir, err)
+	}
+
+	vulnerabilities := make(map[string]string, len(entries))
+	for _, entry := range entries {
+		status, err := stringFromFile(filepath.Join(dir, entry.Name()))
+		if err != nil {
+			return nil, fmt.Errorf("failed to get status of CPU vulnerability %s: %w", entry.Name(), err)
+		}
+		vulnerabilities[entry.Name()] = status
+	}
+
+	slog.Debug("Got CPU vulnerabilities", slog.Any("cpu_vulnerabilities", vulnerabilities))
+
+	return vulnerabilities, nil
+}
@@ -0,0 +1,35 @@
+package resourceutil
+
+import (
+	"errors"
+	"fmt"
+	"log/slog"
+)
+
+// machineIDPaths are the locations of the machine ID, in order of preference.
+var machineIDPaths = []string{"/etc/machine-id", "/var/lib/dbus/machine-id"}
+
+// ErrHostIDNotFound is returned when no machine ID file exists.
+var ErrHostIDNotFound = errors.New("machine ID not found")
+
+// GetHostID retrieves a stable identifier for the host from /etc/machine-id, falling back to /var/lib/dbus/machine-id.
+// Returns an error wrapping ErrHostIDNotFound when neither file exists or both are empty.
+func GetHostID() (string, error) {
+	var errs []error
+	for _, path := range machineIDPaths {
+		id, err := stringFromFile(path)
+		if err != nil {
+			errs = append(errs, err)
+			continue
+		}
+		if id == "" {
+			errs = append(errs, fmt.Errorf("machine ID at path %s is empty", path))
+			continue
+		}
+
+		slog.Debug("Got host ID", slog.String("path", path), slog.String("host_id", id))
+		return id, nil
+	}
+
+	return "", fmt.Errorf("%w: %w", ErrHostIDNotFound, errors.Join(errs...))
+}
@@ -0,0 +1,92 @@
+package resourceutil
+
+import (
+	"errors"
+	"fmt"
+	"log/slog"
+	"os"
+	"strings"
+)
+
+// thpEnabledPath is the sysfs file holding the transparent huge page mode.
+const thpEnabledPath = "/sys/kernel/mm/transparent_hugepage/enabled"
+
+// HugePages represents the state of the huge page pool and transparent huge pages.
+// Fields:
+//   - Total (uint64): The number of huge pages in the pool, typically reserved at boot.
+//   - Free (uint64): The number of huge pages in the pool not yet allocated.
+//   - Reserved (uint64): The number of huge pages committed for allocation but not yet faulted in.
+//   - Surplus (uint64): The number of huge pages allocated above the configured pool size.
+//   - PageSizeKB (uint64): The default huge page size in kilobytes.
+//   - TotalGB (float64): The size of the pool in gigabytes.
+//   - FreeGB (float64): The unallocated part of the pool in gigabytes.
+//   - AnonHugePagesGB (float64): The anonymous memory backed by transparent huge pages in gigabytes.
+//   - TransparentMode (string): The transparent huge page mode, "always", "madvise" or "never", empty if unavailable.
+type HugePages struct {
+	Total           uint64
+	Free            uint64
+	Reserved        uint64
+	Surplus         uint64
+	PageSizeKB      uint64
+	TotalGB         float64
+	FreeGB          float64
+	AnonHugePagesGB float64
+	TransparentMode string
+}
+
+// GetHugePages retrieves the huge page pool counters and transparent huge page usage from /proc/meminfo,
+// along with the transparent huge page mode.
+func GetHugePages() (HugePages, error) {
+	info, err := GetMemInfo()
+	if err != nil {
+		return HugePages{}, err
+	}
+
+	const bytesPerGB = 1024 * 1024 * 1024
+	hugePages := HugePages{
+		Total:           info.HugePagesTotal,
+		Free:            info.HugePagesFree,
+		Reserved:        info.HugePagesRsvd,
+		Surplus:         info.HugePagesSurp,
+		PageSizeKB:      info.Hugepagesize / 1024,
+		TotalGB:         float64(info.HugePagesTotal*info.Hugepagesize) / bytesPerGB,
+		FreeGB:          float64(info.HugePagesFree*info.Hugepagesize) / bytesPerGB,
+		AnonHugePagesGB: float64(info.AnonHugePages) / bytesPerGB,
+	}
+
+	hugePages.TransparentMode, err = transparentHugePageMode()
+	if err != nil && !errors.Is(err, os.ErrNotExist) {
+		return HugePages{}, err
+	}
+
+	slog.Debug("Got huge pages", slog.Any("huge_pages", hugePages))
+
+	return hugePages, nil
+}
+
+// transparentHugePageMode returns the selected transparent huge page mode, which the kernel marks with brackets,
+// e.g. "madvise" for "always [madvise] never".
+func transparentHugePageMode() (string, error) {
+	modes, err := stringFromFile(thpEnabledPath)
+	if err != nil {
+		return "", err
+	}
+
+	mode, ok := selectedOption(modes)
+	if !ok {
+		return "", fmt.Errorf("no selected mode in %s: %s", thpEnabledPath, modes)
+	}
+
+	return mode, nil
+}
+
+// selectedOption returns the option marked with brackets in a sysfs list such as "always [madvise] never".
+func selectedOption(list string) (string, bool) {
+	for _, option := range strings.Fields(list) {
+		if selected, ok := strings.CutPrefix(option, "["); ok {
+			return strings.TrimSuffix(selected, "]"), true
+		}
+	}
+
+	return "", false
+}
@@ -0,0 +1,259 @@
+package resourceutil
+
+import (
+	"bufio"
+	"fmt"
+	"log/slog"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Interrupt represents the counts of an interrupt source from /proc/interrupts.
+// Fields:
+//   - IRQ (string): The IRQ number, or the name of an architecture specific interrupt such as "NMI" or "LOC".
+//   - PerCPU (map[int]uint64): The number of interrupts handled by each online CPU since boot, keyed by CPU number.
+//   - Total (uint64): The number of interrupts handled by all CPUs since boot.
+//   - Description (string): The interrupt controller, trigger type and device names, as reported by the kernel.
+type Interrupt struct {
+	IRQ         string
+	PerCPU      map[int]uint64
+	Total       uint64
+	Description string
+}
+
+// InterruptRate represents the rate of an interrupt source over an interval.
+// Fields:
+//   - IRQ (string): The IRQ number or name.
+//   - PerCPU (map[int]float64): The interrupts per second handled by each CPU, keyed by CPU number.
+//   - Total (float64): The interrupts per second handled by all CPUs.
+//   - Description (string): The interrupt controller, trigger type and device names.
+type InterruptRate struct {
+	IRQ         string
+	PerCPU      map[int]float64
+	Total       float64
+	Description string
+}
+
+// cpuCounterRow is a row of a per-CPU counter table such as /proc/interrupts or /proc/softirqs.
+type cpuCounterRow struct {
+	key         string
+	counts      map[int]uint64
+	description string
+}
+
+// readCPUCounterTable reads a file with a header of CPU columns followed by "KEY: count count ... [description]"
+// rows, as used by /proc/interrupts and /proc/softirqs. Rows with fewer counts than CPUs, such as the
+// ERR and MIS totals in /proc/interrupts, have their counts attributed to the first CPUs.
+func readCPUCounterTable(path string) ([]cpuCounterRow, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		slog.Error("Failed to read CPU counters", slog.String("path", path), slog.Any("error", err))
+		return nil, err
+	}
+	defer file.Close()
+
+	scanner := bufio.NewScanner(file)
+	// Lines grow with the CPU count, so allow more than the default 64 KiB on large machines
+	scanner.Buffer(make([]byte, 0, 64*1024), 1024*1024)
+
+	if !scanner.Scan() {
+		return nil, fmt.Errorf("missing header in %s", path)
+	}
+
+	// Only online CPUs have a column
+	var cpus []int
+	for _, column := range strings.Fields(scanner.Text()) {
+		cpu, err := strconv.Atoi(strings.TrimPrefix(column, "CPU"))
+		if err != nil {
+			return nil, fmt.Errorf("unexpected column %s in %s", column, path)
+		}
+		cpus = append(cpus, cpu)
+	}
+
+	var rows []cpuCounterRow
+	for scanner.Scan() {
+		key, rest, ok := strings.Cut(scanner.Text(), ":")
+		if !ok {
+			continue
+		}
+
+		fields := strings.Fields(rest)
+		row := cpuCounterRow{key: strings.TrimSpace(key), counts: make(map[int]uint64, len(cpus))}
+		i := 0
+		for ; i < len(fields) && i < len(cpus); i++ {
+			count, err := strconv.ParseUint(fields[i], 10, 64)
+			if err != nil {
+				break
+			}
+			row.counts[cpus[i]] = count
+		}
+		row.description = strings.Join(fields[i:], " ")
+
+		rows = append(rows, row)
+	}
+
+	if err := scanner.Err(); err != nil {
+		slog.Error("Failed to scan CPU counters", slog.String("path", path), slog.Any("error", err))
+		return nil, err
+	}
+
+	return rows, nil
+}
+
+// GetInterrupts retrieves the per-CPU counts and device names of every interrupt source in /proc/interrupts, keyed by IRQ.
+func GetInterrupts() (map[string]Interrupt, error) {
+	rows, err := readCPUCounterTable("/proc/interrupts")
+	if err != nil {
+		return nil, err
+	}
+
+	interrupts := make(map[string]Interrupt, len(rows))
+	for _, row := range rows {
+		interrupt := Interrupt{
+			IRQ:         row.key,
+			PerCPU:      row.counts,
+			Description: row.description,
+		}
+		for _, count := range row.counts {
+			interrupt.Total += count
+		}
+		interrupts[row.key] = interrupt
+	}
+
+	return interrupts, nil
+}
+
+// GetInterruptRates measures the rate of every interrupt source over the given interval, keyed by IRQ.
+// This call blocks for the duration of the interval. Interrupt sources and CPUs that appear or disappear
+// during the interval are omitted.
+func GetInterruptRates(interval time.Duration) (map[string]InterruptRate, error) {
+	if interval <= 0 {
+		return nil, fmt.Errorf("interval must be positive, got %s", interval)
+	}
+
+	before, err := GetInterrupts()
+	if err != nil {
+		return nil, err
+	}
+	start := time.Now()
+
+	time.Sleep(interval)
+
+	after, err := GetInterrupts()
+	if err != nil {
+		return nil, err
+	}
+	seconds := time.Since(start).Seconds()
+
+	rates := make(map[string]InterruptRate, len(after))
+	for irq, interrupt := range after {
+		prev, ok := before[irq]
+		if !ok {
+			continue
+		}
+
+		rate := InterruptRate{
+			IRQ:         irq,
+			PerCPU:      make(map[int]float64, len(interrupt.PerCPU)),
+			Description: interrupt.Description,
+		}
+		for cpu, count := range interrupt.PerCPU {
+			prevCount, ok := prev.PerCPU[cpu]
+			if !ok || count < prevCount {
+				continue
+			}
+			rate.PerCPU[cpu] = float64(count-prevCount) / seconds
+			rate.Total += rate.PerCPU[cpu]
+		}
+		rates[irq] = rate
+	}
+
+	return rates, nil
+}
+
+// SoftIRQ represents the counts of a softirq type from /proc/softirqs.
+// Fields:
+//   - Name (string): The softirq type, e.g. "NET_RX", "NET_TX" or "TIMER".
+//   - PerCPU (map[int]uint64): The number of softirqs handled by each online CPU since boot, keyed by CPU number.
+//   - Total (uint64): The number of softirqs handled by all CPUs since boot.
+type SoftIRQ struct {
+	Name   string
+	PerCPU map[int]uint64
+	Total  uint64
+}
+
+// SoftIRQRate represents the rate of a softirq type over an interval.
+// Fields:
+//   - Name (string): The softirq type.
+//   - PerCPU (map[int]float64): The softirqs per second handled by each CPU, keyed by CPU number.
+//   - Total (float64): The softirqs per second handled by all CPUs.
+type SoftIRQRate struct {
+	Name   string
+	PerCPU map[int]float64
+	Total  float64
+}
+
+// GetSoftIRQs retrieves the per-CPU counts of every softirq type in /proc/softirqs, keyed by name.
+// High NET_RX counts concentrated on a few CPUs point to packet processing that is not spread across cores.
+func GetSoftIRQs() (map[string]SoftIRQ, error) {
+	rows, err := readCPUCounterTable("/proc/softirqs")
+	if err != nil {
+		return nil, err
+	}
+
+	softIRQs := make(map[string]SoftIRQ, len(rows))
+	for _, row := range rows {
+		softIRQ := SoftIRQ{Name: row.key, PerCPU: row.counts}
+		for _, count := range row.counts {
+			softIRQ.Total += count
+		}
+		softIRQs[row.key] = softIRQ
+	}
+
+	return softIRQs, nil
+}
+
+// GetSoftIRQRates measures the rate of every softirq type over the given interval, keyed by name.
+// This call blocks for the duration of the interval. CPUs that go offline during the interval are omitted.
+func GetSoftIRQRates(interval time.Duration) (map[string]SoftIRQRate, error) {
+	if interval <= 0 {
+		return nil, fmt.Errorf("interval must be positive, got %s", interval)
+	}
+
+	before, err := GetSoftIRQs()
+	if err != nil {
+		return nil, err
+	}
+	start := time.Now()
+
+	time.Sleep(interval)
+
+	after, err := GetSoftIRQs()
+	if err != nil {
+		return nil, err
+	}
+	seconds := time.Since(start).Seconds()
+
+	rates := make(map[string]SoftIRQRate, len(after))
+	for name, softIRQ := range after {
+		prev, ok := before[name]
+		if !ok {
+			continue
+		}
+
+		rate := SoftIRQRate{Name: name, PerCPU: make(map[int]float64, len(softIRQ.PerCPU))}
+		for cpu, count := range softIRQ.PerCPU {
+			prevCount, ok := prev.PerCPU[cpu]
+			if !ok || count < prevCount {
+				continue
+			}
+			rate.PerCPU[cpu] = float64(count-prevCount) / seconds
+			rate.Total += rate.PerCPU[cpu]
+		}
+		rates[name] = rate
+	}
+
+	return rates, nil
+}
@@ -0,0 +1,85 @@
+package resourceutil
+
+import (
+	"errors"
+	"fmt"
+	"log/slog"
+	"os"
+	"path/filepath"
+)
+
+// ksmSysDir is the sysfs directory of the kernel samepage merging counters.
+const ksmSysDir = "/sys/kernel/mm/ksm"
+
+// ErrKSMUnsupported is returned when the kernel is built without kernel samepage merging.
+var ErrKSMUnsupported = errors.New("KSM not supported by the kernel")
+
+// KSMStats represents the state of kernel samepage merging (KSM), which deduplicates identical memory pages,
+// mostly of virtual machines.
+// Fields:
+//   - Running (bool): Whether the KSM daemon is merging pages.
+//   - PagesShared (uint64): The number of deduplicated pages in use.
+//   - PagesSharing (uint64): The number of page mappings sharing a deduplicated page, i.e. the pages saved.
+//   - PagesUnshared (uint64): The number of unique pages repeatedly checked for merging.
+//   - PagesVolatile (uint64): The number of pages changing too fast to be merged.
+//   - FullScans (uint64): The number of times all mergeable memory has been scanned.
+//   - SavedGB (float64): The memory saved by sharing pages in gigabytes.
+//   - GeneralProfitGB (float64): The memory saved minus the metadata KSM uses in gigabytes, negative when KSM costs
+//     more than it saves. Only reported by Linux 6.1 and later, 0 otherwise.
+//   - SharingRatio (float64): PagesSharing divided by PagesShared, 0 when no pages are shared.
+type KSMStats struct {
+	Running         bool
+	PagesShared     uint64
+	PagesSharing    uint64
+	PagesUnshared   uint64
+	PagesVolatile   uint64
+	FullScans       uint64
+	SavedGB         float64
+	GeneralProfitGB float64
+	SharingRatio    float64
+}
+
+// GetKSMStats retrieves the kernel samepage merging counters from /sys/kernel/mm/ksm.
+// Returns ErrKSMUnsupported if the kernel has no KSM.
+func GetKSMStats() (KSMStats, error) {
+	run, err := intFromFile(filepath.Join(ksmSysDir, "run"))
+	if err != nil {
+		if errors.Is(err, os.ErrNotExist) {
+			return KSMStats{}, ErrKSMUnsupported
+		}
+		return KSMStats{}, fmt.Errorf("failed to get KSM state: %w", err)
+	}
+
+	stats := KSMStats{Running: run == 1}
+	for _, counter := range []struct {
+		file  string
+		value *uint64
+	}{
+		{"pages_shared", &stats.PagesShared},
+		{"pages_sharing", &stats.PagesSharing},
+		{"pages_unshared", &stats.PagesUnshared},
+		{"pages_volatile", &stats.PagesVolatile},
+		{"full_scans", &stats.FullScans},
+	} {
+		if *counter.value, err = uint64FromFile(filepath.Join(ksmSysDir, counter.file)); err != nil {
+			return KSMStats{}, fmt.Errorf("failed to get KSM %s: %w", counter.file, err)
+		}
+	}
+
+	const bytesPerGB = 1024 * 1024 * 1024
+	stats.SavedGB = float64(stats.PagesSharing*uint64(os.Getpagesize())) / bytesPerGB
+	if stats.PagesShared > 0 {
+		stats.SharingRatio = float64(stats.PagesSharing) / float64(stats.PagesShared)
+	}
+
+	// general_profit is in bytes and may be negative
+	profit, err := intFromFile(filepath.Join(ksmSysDir, "general_profit"))
+	if err != nil && !errors.Is(err, os.ErrNotExist) {
+		return KSMStats{}, fmt.Errorf("failed to get KSM general profit: %w", err)
+	}
+	stats.GeneralProfitGB = float64(profit) / bytesPerGB
+
+	slog.Debug("Got KSM stats", slog.Any("ksm_stats", stats))
+
+	return stats, nil
+}
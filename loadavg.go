@@ -0,0 +1,94 @@
+package resourceutil
+
+import (
+	"fmt"
+	"log/slog"
+	"strconv"
+	"strings"
+)
+
+// LoadAvg represents the kernel load averages and scheduling entity counts from /proc/loadavg.
+// Fields:
+//   - One (float64): The load average over the last minute.
+//   - Five (float64): The load average over the last 5 minutes.
+//   - Fifteen (float64): The load average over the last 15 minutes.
+//   - Runnable (int): The number of currently runnable scheduling entities (processes and threads).
+//   - Total (int): The total number of scheduling entities on the system.
+type LoadAvg struct {
+	One      float64
+	Five     float64
+	Fifteen  float64
+	Runnable int
+	Total    int
+}
+
+// GetLoadAvg retrieves the kernel's 1, 5 and 15 minute load averages along with the runnable and total
+// process counts. Unlike GetCPULoad this is the kernel's own run-queue based load, not sampled utilization.
+func GetLoadAvg() (LoadAvg, error) {
+	avg, err := readLoadAvg()
+	if err != nil {
+		return LoadAvg{}, err
+	}
+
+	slog.Debug("Got load average", slog.Any("load_avg", avg))
+
+	return avg, nil
+}
+
+// readLoadAvg reads and parses /proc/loadavg.
+func readLoadAvg() (LoadAvg, error) {
+	data, err := stringFromFile("/proc/loadavg")
+	if err != nil {
+		slog.Error("Failed to read load average", slog.String("path", "/proc/loadavg"), slog.Any("error", err))
+		return LoadAvg{}, err
+	}
+
+	// Format: 1min 5min 15min runnable/total last_pid
+	fields := strings.Fields(data)
+	if len(fields) < 4 {
+		return LoadAvg{}, fmt.Errorf("unexpected format in /proc/loadavg: %s", data)
+	}
+
+	var avg LoadAvg
+	for i, dst := range []*float64{&avg.One, &avg.Five, &avg.Fifteen} {
+		*dst, err = strconv.ParseFloat(fields[i], 64)
+		if err != nil {
+			return LoadAvg{}, fmt.Errorf("failed to parse load average field %d: %w", i, err)
+		}
+	}
+
+	runnable, total, ok := strings.Cut(fields[3], "/")
+	if !ok {
+		return LoadAvg{}, fmt.Errorf("unexpected entity counts in /proc/loadavg: %s", fields[3])
+	}
+	avg.Runnable, err = strconv.Atoi(runnable)
+	if err != nil {
+		return LoadAvg{}, fmt.Errorf("failed to parse runnable entities: %w", err)
+	}
+	avg.Total, err = strconv.Atoi(total)
+	if err != nil {
+		return LoadAvg{}, fmt.Errorf("failed to parse total entities: %w", err)
+	}
+
+	return avg, nil
+}
+
+// GetNormalizedLoadAverage retrieves the 1, 5 and 15 minute load averages divided by the number of online CPUs.
+// Values above 1.0 mean the CPUs are oversubscribed regardless of the size of the machine.
+func GetNormalizedLoadAverage() (one, five, fifteen float64, err error) {
+	avg, err := readLoadAvg()
+	if err != nil {
+		return 0, 0, 0, err
+	}
+
+	cpus, err := onlineCPUs()
+	if err != nil {
+		return 0, 0, 0, err
+	}
+	if len(cpus) == 0 {
+		return 0, 0, 0, fmt.Errorf("divide by zero: no online CPUs")
+	}
+
+	count := float64(len(cpus))
+	return avg.One / count, avg.Five / count, avg.Fifteen / count, nil
+}
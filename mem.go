@@ -5,84 +5,219 @@ import (
 	"fmt"
 	"log/slog"
 	"os"
-	"regexp"
-	"strconv"
+	"time"
 )
 
 // MemUsage represents memory usage metrics.
 // Fields:
 //   - TotalGB (float64): The total memory size in gigabytes.
-//   - FreeGB (float64): The available memory in gigabytes.
+//   - AvailableGB (float64): The available memory in gigabytes.
+//   - FreeGB (float64): The completely unused memory in gigabytes.
 //   - UsedGB (float64): The amount of used memory in gigabytes.
 //   - UsedPercent (float64): The percentage of memory in use.
+//   - BuffersGB (float64): The memory used for raw disk block buffers in gigabytes.
+//   - CachedGB (float64): The memory used by the page cache in gigabytes, including tmpfs and shared memory.
+//   - SReclaimableGB (float64): The kernel slab memory that can be reclaimed, such as dentry caches, in gigabytes.
+//   - MlockedGB (float64): The memory locked into RAM with mlock in gigabytes.
+//   - UnevictableGB (float64): The memory that cannot be reclaimed, including mlocked memory and ramfs, in gigabytes.
+//   - TotalBytes, AvailableBytes, FreeBytes, UsedBytes, BuffersBytes, CachedBytes, SReclaimableBytes, MlockedBytes,
+//     UnevictableBytes (uint64): The exact values of the corresponding GB fields in bytes, e.g. for exporting to Prometheus.
+//
+// AvailableGB is the kernel's estimate of how much memory can be allocated without swapping, including cache that
+// can be reclaimed, while FreeGB counts only memory holding nothing at all. FreeGB is usually far lower on a healthy
+// system since the kernel uses idle memory for caching, so prefer AvailableGB unless the truly unused memory matters.
+//
+// Buffers, cache and reclaimable slab are mostly counted as available rather than used, so they show how much
+// of the memory that looks consumed is actually cache the kernel gives back under pressure. For cgroup memory
+// usage only CachedGB is set of these, from memory.stat, and it is included in UsedGB as the kernel charges it
+// to the cgroup. FreeGB equals AvailableGB for cgroup memory usage, the remaining room below the limit.
+//
+// Mlocked and unevictable memory is pinned in RAM and never reclaimed or swapped, so it bounds how far the
+// kernel can free memory under pressure. Both are host-wide and not set for cgroup memory usage.
 type MemUsage struct {
-	TotalGB     float64
-	AvailableGB float64
-	UsedGB      float64
-	UsedPercent float64
+	TotalGB        float64
+	AvailableGB    float64
+	FreeGB         float64
+	UsedGB         float64
+	UsedPercent    float64
+	BuffersGB      float64
+	CachedGB       float64
+	SReclaimableGB float64
+	MlockedGB      float64
+	UnevictableGB  float64
+
+	TotalBytes        uint64
+	AvailableBytes    uint64
+	FreeBytes         uint64
+	UsedBytes         uint64
+	BuffersBytes      uint64
+	CachedBytes       uint64
+	SReclaimableBytes uint64
+	MlockedBytes      uint64
+	UnevictableBytes  uint64
 }
 
-func GetMemUsage() (MemUsage, error) {
-	memStr, err := readMemInfo()
-	if err != nil {
-		return MemUsage{}, err
+// memUsageOptions holds the settings used by GetMemUsage.
+type memUsageOptions struct {
+	cgroup bool
+}
+
+// MemUsageOption configures GetMemUsage.
+type MemUsageOption func(*memUsageOptions)
+
+// WithCgroupMem makes GetMemUsage report the memory usage of the cgroup of the calling process relative to its
+// memory limit instead of host-wide usage from /proc/meminfo, which is what matters inside containers such as
+// Kubernetes pods. Without a limit the usage is relative to the total memory of the host. Both cgroup v2
+// (memory.current and memory.max) and cgroup v1 (memory.usage_in_bytes and memory.limit_in_bytes) are supported.
+func WithCgroupMem() MemUsageOption {
+	return func(o *memUsageOptions) {
+		o.cgroup = true
 	}
+}
 
-	memAvailable, err := extractMemValue(memStr, "MemAvailable")
-	if err != nil {
-		return MemUsage{}, err
+// GetMemUsage retrieves the memory usage of the host from /proc/meminfo, or of the calling process's cgroup
+// WithCgroupMem.
+func GetMemUsage(opts ...MemUsageOption) (MemUsage, error) {
+	var options memUsageOptions
+	for _, opt := range opts {
+		opt(&options)
+	}
+
+	if options.cgroup {
+		mem, err := readSelfCgroupMemory()
+		if err != nil {
+			return MemUsage{}, fmt.Errorf("failed to get cgroup memory usage: %w", err)
+		}
+		return cgroupMemUsage(mem)
 	}
-	slog.Debug("Retrieved available memory", slog.Float64("available_memory_GB", memAvailable))
 
-	memTotal, err := extractMemValue(memStr, "MemTotal")
+	info, err := GetMemInfo()
 	if err != nil {
 		return MemUsage{}, err
 	}
-	slog.Debug("Retrieved total memory", slog.Float64("total_memory_GB", memTotal))
 
-	if memTotal == 0 {
+	return hostMemUsage(info)
+}
+
+// hostMemUsage calculates the memory usage of the host from the fields of /proc/meminfo.
+func hostMemUsage(info MemInfo) (MemUsage, error) {
+	for _, key := range []string{"MemTotal", "MemAvailable"} {
+		if !info.Has(key) {
+			return MemUsage{}, fmt.Errorf("could not find %s information in /proc/meminfo", key)
+		}
+	}
+	if info.MemTotal == 0 {
 		return MemUsage{}, errors.New("divide by zero: total memory is zero")
 	}
 
-	usagePercent := 100 * float64(memTotal-memAvailable) / float64(memTotal)
-	slog.Debug("Calculated memory usage", slog.Float64("usage_percent", usagePercent))
-
+	used := info.MemTotal - min(info.MemAvailable, info.MemTotal)
+	const bytesPerGB = 1024 * 1024 * 1024
 	memUsage := MemUsage{
-		TotalGB:     memTotal,
-		AvailableGB: memAvailable,
-		UsedGB:      memTotal - memAvailable,
-		UsedPercent: usagePercent,
+		TotalGB:        float64(info.MemTotal) / bytesPerGB,
+		AvailableGB:    float64(info.MemAvailable) / bytesPerGB,
+		FreeGB:         float64(info.MemFree) / bytesPerGB,
+		UsedGB:         float64(used) / bytesPerGB,
+		UsedPercent:    100 * float64(used) / float64(info.MemTotal),
+		BuffersGB:      float64(info.Buffers) / bytesPerGB,
+		CachedGB:       float64(info.Cached) / bytesPerGB,
+		SReclaimableGB: float64(info.SReclaimable) / bytesPerGB,
+		MlockedGB:      float64(info.Mlocked) / bytesPerGB,
+		UnevictableGB:  float64(info.Unevictable) / bytesPerGB,
+
+		TotalBytes:        info.MemTotal,
+		AvailableBytes:    info.MemAvailable,
+		FreeBytes:         info.MemFree,
+		UsedBytes:         used,
+		BuffersBytes:      info.Buffers,
+		CachedBytes:       info.Cached,
+		SReclaimableBytes: info.SReclaimable,
+		MlockedBytes:      info.Mlocked,
+		UnevictableBytes:  info.Unevictable,
 	}
+	slog.Debug("Retrieved memory", slog.Float64("total_memory_GB", memUsage.TotalGB), slog.Float64("available_memory_GB", memUsage.AvailableGB))
+	slog.Debug("Calculated memory usage", slog.Float64("usage_percent", memUsage.UsedPercent))
+	slog.Debug("Retrieved cache memory", slog.Float64("buffers_GB", memUsage.BuffersGB), slog.Float64("cached_GB", memUsage.CachedGB), slog.Float64("sreclaimable_GB", memUsage.SReclaimableGB))
 
 	return memUsage, nil
 }
 
 // readMemInfo reads and returns the contents of /proc/meminfo.
-func readMemInfo() (string, error) {
+func readMemInfo() ([]byte, error) {
 	b, err := os.ReadFile("/proc/meminfo")
 	if err != nil {
 		slog.Error("Failed to read memory info", slog.String("path", "/proc/meminfo"), slog.Any("error", err))
-		return "", err
+		return nil, err
 	}
-	slog.Debug("Read memory info", slog.String("mem_info", string(b)))
-	return string(b), nil
+	return b, nil
 }
 
-// extractMemoryValue uses a regex to extract an integer value in kB from /proc/meminfo based on the key.
-// Returns the memory value in GB.
-func extractMemValue(memStr, key string) (float64, error) {
-	re := regexp.MustCompile(fmt.Sprintf(`%s:\s*(\d+) kB`, key))
-	matches := re.FindStringSubmatch(memStr)
+// readNodeMemInfo reads and returns the contents of the meminfo file of a NUMA node.
+// Each line is prefixed with "Node <n>" but otherwise uses the /proc/meminfo format.
+func readNodeMemInfo(node int) ([]byte, error) {
+	path := fmt.Sprintf("/sys/devices/system/node/node%d/meminfo", node)
+	b, err := os.ReadFile(path)
+	if err != nil {
+		slog.Error("Failed to read node memory info", slog.String("path", path), slog.Any("error", err))
+		return nil, err
+	}
+	return b, nil
+}
+
+// DirtyMemory represents the memory waiting to be written back to disk.
+// Fields:
+//   - DirtyGB (float64): The memory modified but not yet written back in gigabytes.
+//   - WritebackGB (float64): The memory actively being written back in gigabytes.
+//   - DirtyRateGBPerSec (float64): The change of DirtyGB per second over the interval, negative while draining.
+//     Only set by GetDirtyMemoryRate.
+//   - WritebackRateGBPerSec (float64): The change of WritebackGB per second over the interval.
+//     Only set by GetDirtyMemoryRate.
+type DirtyMemory struct {
+	DirtyGB               float64
+	WritebackGB           float64
+	DirtyRateGBPerSec     float64
+	WritebackRateGBPerSec float64
+}
+
+// GetDirtyMemory retrieves the dirty and writeback memory from /proc/meminfo.
+func GetDirtyMemory() (DirtyMemory, error) {
+	info, err := GetMemInfo()
+	if err != nil {
+		return DirtyMemory{}, err
+	}
+
+	const bytesPerGB = 1024 * 1024 * 1024
+	return DirtyMemory{
+		DirtyGB:     float64(info.Dirty) / bytesPerGB,
+		WritebackGB: float64(info.Writeback) / bytesPerGB,
+	}, nil
+}
+
+// GetDirtyMemoryRate measures how fast dirty and writeback memory change over the given interval,
+// reporting the values at the end of the interval. Dirty memory growing quickly while writeback stays
+// high shows a box buffering more writes than the disk absorbs, which ends in stalls once the dirty
+// limit is reached. This call blocks for the duration of the interval.
+func GetDirtyMemoryRate(interval time.Duration) (DirtyMemory, error) {
+	if interval <= 0 {
+		return DirtyMemory{}, fmt.Errorf("interval must be positive, got %s", interval)
+	}
 
-	if len(matches) < 2 {
-		return 0, fmt.Errorf("could not find %s information in /proc/meminfo", key)
+	before, err := GetDirtyMemory()
+	if err != nil {
+		return DirtyMemory{}, err
 	}
+	start := time.Now()
 
-	valuekB, err := strconv.Atoi(matches[1])
+	time.Sleep(interval)
+
+	after, err := GetDirtyMemory()
 	if err != nil {
-		return 0, fmt.Errorf("failed to parse %s value: %w", key, err)
+		return DirtyMemory{}, err
 	}
+	seconds := time.Since(start).Seconds()
+
+	after.DirtyRateGBPerSec = (after.DirtyGB - before.DirtyGB) / seconds
+	after.WritebackRateGBPerSec = (after.WritebackGB - before.WritebackGB) / seconds
+	slog.Debug("Measured dirty memory rate", slog.Any("dirty_memory", after))
 
-	valueGB := float64(valuekB) / (1024 * 1024)
-	return valueGB, nil
+	return after, nil
 }
@@ -0,0 +1,350 @@
+package resourceutil
+
+import (
+	"bytes"
+	"fmt"
+	"sync"
+)
+
+// MemInfo represents the contents of /proc/meminfo.
+// Sizes are in bytes, except for the HugePages_* fields which count pages. Fields not reported by the
+// running kernel are zero.
+// Fields:
+//   - MemTotal (uint64): Total usable RAM.
+//   - MemFree (uint64): RAM left completely unused.
+//   - MemAvailable (uint64): Estimate of the memory available for starting new applications without swapping.
+//   - Buffers (uint64): Temporary storage for raw disk blocks.
+//   - Cached (uint64): Page cache, excluding SwapCached.
+//   - SwapCached (uint64): Memory that was swapped out and back in but is still in the swap file.
+//   - Active (uint64): Memory used recently, usually not reclaimed unless necessary.
+//   - Inactive (uint64): Memory used less recently, more eligible for reclaim.
+//   - ActiveAnon (uint64): Active anonymous memory.
+//   - InactiveAnon (uint64): Inactive anonymous memory.
+//   - ActiveFile (uint64): Active file-backed memory.
+//   - InactiveFile (uint64): Inactive file-backed memory.
+//   - Unevictable (uint64): Memory that cannot be reclaimed, e.g. mlocked pages.
+//   - Mlocked (uint64): Memory locked with mlock.
+//   - SwapTotal (uint64): Total swap space.
+//   - SwapFree (uint64): Unused swap space.
+//   - Zswap (uint64): Memory consumed by the zswap backend.
+//   - Zswapped (uint64): Amount of anonymous memory stored in zswap, before compression.
+//   - Dirty (uint64): Memory waiting to be written back to disk.
+//   - Writeback (uint64): Memory actively being written back to disk.
+//   - AnonPages (uint64): Non-file backed pages mapped into user-space page tables.
+//   - Mapped (uint64): Files mapped into memory, such as libraries.
+//   - Shmem (uint64): Shared memory and tmpfs.
+//   - KReclaimable (uint64): Kernel allocations the kernel will reclaim under memory pressure.
+//   - Slab (uint64): In-kernel data structures cache.
+//   - SReclaimable (uint64): Part of Slab that might be reclaimed, such as caches.
+//   - SUnreclaim (uint64): Part of Slab that cannot be reclaimed under memory pressure.
+//   - KernelStack (uint64): Memory used by kernel stacks.
+//   - PageTables (uint64): Memory used by page tables.
+//   - SecPageTables (uint64): Memory used by secondary page tables, e.g. for KVM.
+//   - NFSUnstable (uint64): NFS pages sent to the server but not yet committed to stable storage.
+//   - Bounce (uint64): Memory used for block device bounce buffers.
+//   - WritebackTmp (uint64): Memory used by FUSE for temporary writeback buffers.
+//   - CommitLimit (uint64): Total memory that can be allocated under strict overcommit.
+//   - CommittedAS (uint64): Memory currently allocated on the system, whether used or not.
+//   - VmallocTotal (uint64): Total size of the vmalloc area.
+//   - VmallocUsed (uint64): Used part of the vmalloc area.
+//   - VmallocChunk (uint64): Largest contiguous free block of the vmalloc area.
+//   - Percpu (uint64): Memory allocated to the percpu allocator.
+//   - HardwareCorrupted (uint64): Memory the kernel identified as corrupted.
+//   - AnonHugePages (uint64): Anonymous transparent huge pages mapped into user-space page tables.
+//   - ShmemHugePages (uint64): Shared memory and tmpfs allocated with huge pages.
+//   - ShmemPmdMapped (uint64): Shared memory mapped into user space with huge pages.
+//   - FileHugePages (uint64): Page cache allocated with huge pages.
+//   - FilePmdMapped (uint64): Page cache mapped into user space with huge pages.
+//   - CmaTotal (uint64): Memory reserved for the contiguous memory allocator.
+//   - CmaFree (uint64): Free memory in the contiguous memory allocator reserves.
+//   - HugePagesTotal (uint64): Size of the pool of huge pages, as a number of pages.
+//   - HugePagesFree (uint64): Number of huge pages in the pool not yet allocated.
+//   - HugePagesRsvd (uint64): Number of huge pages committed for allocation but not yet allocated.
+//   - HugePagesSurp (uint64): Number of huge pages in the pool above nr_hugepages.
+//   - Hugepagesize (uint64): Default size of huge pages.
+//   - Hugetlb (uint64): Total memory consumed by huge pages of all sizes.
+//   - DirectMap4k (uint64): Memory mapped by the kernel with 4 kB pages.
+//   - DirectMap2M (uint64): Memory mapped by the kernel with 2 MB pages.
+//   - DirectMap1G (uint64): Memory mapped by the kernel with 1 GB pages.
+//   - Other (map[string]uint64): Fields not listed above, keyed by their name in /proc/meminfo, sizes in bytes.
+//     Nil if the kernel reports no such fields.
+//
+// Use Has to tell a field the kernel did not report apart from one that is zero.
+type MemInfo struct {
+	MemTotal          uint64
+	MemFree           uint64
+	MemAvailable      uint64
+	Buffers           uint64
+	Cached            uint64
+	SwapCached        uint64
+	Active            uint64
+	Inactive          uint64
+	ActiveAnon        uint64
+	InactiveAnon      uint64
+	ActiveFile        uint64
+	InactiveFile      uint64
+	Unevictable       uint64
+	Mlocked           uint64
+	SwapTotal         uint64
+	SwapFree          uint64
+	Zswap             uint64
+	Zswapped          uint64
+	Dirty             uint64
+	Writeback         uint64
+	AnonPages         uint64
+	Mapped            uint64
+	Shmem             uint64
+	KReclaimable      uint64
+	Slab              uint64
+	SReclaimable      uint64
+	SUnreclaim        uint64
+	KernelStack       uint64
+	PageTables        uint64
+	SecPageTables     uint64
+	NFSUnstable       uint64
+	Bounce            uint64
+	WritebackTmp      uint64
+	CommitLimit       uint64
+	CommittedAS       uint64
+	VmallocTotal      uint64
+	VmallocUsed       uint64
+	VmallocChunk      uint64
+	Percpu            uint64
+	HardwareCorrupted uint64
+	AnonHugePages     uint64
+	ShmemHugePages    uint64
+	ShmemPmdMapped    uint64
+	FileHugePages     uint64
+	FilePmdMapped     uint64
+	CmaTotal          uint64
+	CmaFree           uint64
+	HugePagesTotal    uint64
+	HugePagesFree     uint64
+	HugePagesRsvd     uint64
+	HugePagesSurp     uint64
+	Hugepagesize      uint64
+	Hugetlb           uint64
+	DirectMap4k       uint64
+	DirectMap2M       uint64
+	DirectMap1G       uint64
+	Other             map[string]uint64
+
+	// present has the bits of the keys reported by the kernel set, see memInfoField.
+	present uint64
+}
+
+// memInfoField returns the field of m holding the value of a known key of /proc/meminfo and its bit in
+// m.present, or nil for unknown keys. A switch is used rather than a map of accessor functions since calling
+// those would make m escape to the heap. The indices must be unique and below 64 to fit into present.
+func memInfoField(m *MemInfo, key []byte) (*uint64, int) {
+	// Switching on a converted byte slice does not allocate
+	switch string(key) {
+	case "MemTotal":
+		return &m.MemTotal, 0
+	case "MemFree":
+		return &m.MemFree, 1
+	case "MemAvailable":
+		return &m.MemAvailable, 2
+	case "Buffers":
+		return &m.Buffers, 3
+	case "Cached":
+		return &m.Cached, 4
+	case "SwapCached":
+		return &m.SwapCached, 5
+	case "Active":
+		return &m.Active, 6
+	case "Inactive":
+		return &m.Inactive, 7
+	case "Active(anon)":
+		return &m.ActiveAnon, 8
+	case "Inactive(anon)":
+		return &m.InactiveAnon, 9
+	case "Active(file)":
+		return &m.ActiveFile, 10
+	case "Inactive(file)":
+		return &m.InactiveFile, 11
+	case "Unevictable":
+		return &m.Unevictable, 12
+	case "Mlocked":
+		return &m.Mlocked, 13
+	case "SwapTotal":
+		return &m.SwapTotal, 14
+	case "SwapFree":
+		return &m.SwapFree, 15
+	case "Zswap":
+		return &m.Zswap, 16
+	case "Zswapped":
+		return &m.Zswapped, 17
+	case "Dirty":
+		return &m.Dirty, 18
+	case "Writeback":
+		return &m.Writeback, 19
+	case "AnonPages":
+		return &m.AnonPages, 20
+	case "Mapped":
+		return &m.Mapped, 21
+	case "Shmem":
+		return &m.Shmem, 22
+	case "KReclaimable":
+		return &m.KReclaimable, 23
+	case "Slab":
+		return &m.Slab, 24
+	case "SReclaimable":
+		return &m.SReclaimable, 25
+	case "SUnreclaim":
+		return &m.SUnreclaim, 26
+	case "KernelStack":
+		return &m.KernelStack, 27
+	case "PageTables":
+		return &m.PageTables, 28
+	case "SecPageTables":
+		return &m.SecPageTables, 29
+	case "NFS_Unstable":
+		return &m.NFSUnstable, 30
+	case "Bounce":
+		return &m.Bounce, 31
+	case "WritebackTmp":
+		return &m.WritebackTmp, 32
+	case "CommitLimit":
+		return &m.CommitLimit, 33
+	case "Committed_AS":
+		return &m.CommittedAS, 34
+	case "VmallocTotal":
+		return &m.VmallocTotal, 35
+	case "VmallocUsed":
+		return &m.VmallocUsed, 36
+	case "VmallocChunk":
+		return &m.VmallocChunk, 37
+	case "Percpu":
+		return &m.Percpu, 38
+	case "HardwareCorrupted":
+		return &m.HardwareCorrupted, 39
+	case "AnonHugePages":
+		return &m.AnonHugePages, 40
+	case "ShmemHugePages":
+		return &m.ShmemHugePages, 41
+	case "ShmemPmdMapped":
+		return &m.ShmemPmdMapped, 42
+	case "FileHugePages":
+		return &m.FileHugePages, 43
+	case "FilePmdMapped":
+		return &m.FilePmdMapped, 44
+	case "CmaTotal":
+		return &m.CmaTotal, 45
+	case "CmaFree":
+		return &m.CmaFree, 46
+	case "HugePages_Total":
+		return &m.HugePagesTotal, 47
+	case "HugePages_Free":
+		return &m.HugePagesFree, 48
+	case "HugePages_Rsvd":
+		return &m.HugePagesRsvd, 49
+	case "HugePages_Surp":
+		return &m.HugePagesSurp, 50
+	case "Hugepagesize":
+		return &m.Hugepagesize, 51
+	case "Hugetlb":
+		return &m.Hugetlb, 52
+	case "DirectMap4k":
+		return &m.DirectMap4k, 53
+	case "DirectMap2M":
+		return &m.DirectMap2M, 54
+	case "DirectMap1G":
+		return &m.DirectMap1G, 55
+	}
+	return nil, 0
+}
+
+// GetMemInfo retrieves all fields of /proc/meminfo.
+func GetMemInfo() (MemInfo, error) {
+	data, err := readMemInfo()
+	if err != nil {
+		return MemInfo{}, err
+	}
+
+	return parseMemInfo(data)
+}
+
+// Has reports whether the kernel reported the key, given by its name in /proc/meminfo such as "MemAvailable".
+func (m MemInfo) Has(key string) bool {
+	if field, index := memInfoField(&m, []byte(key)); field != nil {
+		return m.present&(1<<index) != 0
+	}
+	_, ok := m.Other[key]
+	return ok
+}
+
+// otherMemInfoKeys interns the keys of /proc/meminfo not known to MemInfo, so parsing allocates each of
+// their names once rather than on every call.
+var (
+	otherMemInfoKeysMu sync.Mutex
+	otherMemInfoKeys   = make(map[string]string)
+)
+
+// internMemInfoKey returns the interned string of an unknown /proc/meminfo key.
+func internMemInfoKey(key []byte) string {
+	otherMemInfoKeysMu.Lock()
+	defer otherMemInfoKeysMu.Unlock()
+
+	// Looking up a converted byte slice does not allocate
+	if interned, ok := otherMemInfoKeys[string(key)]; ok {
+		return interned
+	}
+	interned := string(key)
+	otherMemInfoKeys[interned] = interned
+	return interned
+}
+
+// parseMemInfo parses the contents of /proc/meminfo in a single pass over its lines. Known keys are parsed
+// without allocating, only the Other map is allocated when the kernel reports keys not known to MemInfo.
+// Lines may be prefixed with "Node <n>" as in the meminfo files of NUMA nodes.
+func parseMemInfo(data []byte) (MemInfo, error) {
+	var info MemInfo
+	var buf [4][]byte
+	fields := buf[:0]
+
+	for len(data) > 0 {
+		line := data
+		if i := bytes.IndexByte(data, '\n'); i >= 0 {
+			line, data = data[:i], data[i+1:]
+		} else {
+			data = nil
+		}
+
+		i := bytes.IndexByte(line, ':')
+		if i < 0 {
+			continue
+		}
+		key := line[:i]
+		if rest, ok := bytes.CutPrefix(key, []byte("Node ")); ok {
+			// Strip the "Node <n> " prefix of node meminfo files
+			if j := bytes.IndexByte(rest, ' '); j >= 0 {
+				key = rest[j+1:]
+			}
+		}
+
+		fields = splitFields(fields[:0], line[i+1:])
+		if len(fields) == 0 {
+			continue
+		}
+		value, err := parseUint(fields[0])
+		if err != nil {
+			return MemInfo{}, fmt.Errorf("failed to parse %s value: %w", key, err)
+		}
+		if len(fields) > 1 && string(fields[1]) == "kB" {
+			value *= 1024
+		}
+
+		if field, index := memInfoField(&info, key); field != nil {
+			*field = value
+			info.present |= 1 << index
+		} else {
+			if info.Other == nil {
+				info.Other = make(map[string]uint64)
+			}
+			info.Other[internMemInfoKey(key)] = value
+		}
+	}
+
+	return info, nil
+}
@@ -0,0 +1,82 @@
+package resourceutil
+
+import (
+	"os"
+	"testing"
+)
+
+// readMemInfoFixture reads a /proc/meminfo captured from a Linux 6.x machine.
+func readMemInfoFixture(tb testing.TB) []byte {
+	tb.Helper()
+	data, err := os.ReadFile("testdata/meminfo")
+	if err != nil {
+		tb.Fatal(err)
+	}
+	return data
+}
+
+func TestParseMemInfo(t *testing.T) {
+	info, err := parseMemInfo([]byte("MemTotal:       16384 kB\nMemAvailable:    8192 kB\nHugePages_Total:       2\nBalloon:           4 kB\n"))
+	if err != nil {
+		t.Fatalf("parseMemInfo() error = %v", err)
+	}
+
+	if info.MemTotal != 16384*1024 {
+		t.Errorf("MemTotal = %d, want %d", info.MemTotal, 16384*1024)
+	}
+	if info.HugePagesTotal != 2 {
+		t.Errorf("HugePagesTotal = %d, want 2", info.HugePagesTotal)
+	}
+	if got := info.Other["Balloon"]; got != 4*1024 {
+		t.Errorf(`Other["Balloon"] = %d, want %d`, got, 4*1024)
+	}
+	for key, want := range map[string]bool{"MemTotal": true, "Balloon": true, "MemFree": false, "Unknown": false} {
+		if got := info.Has(key); got != want {
+			t.Errorf("Has(%q) = %t, want %t", key, got, want)
+		}
+	}
+}
+
+func TestHostMemUsageMissingKey(t *testing.T) {
+	for _, data := range []string{
+		"MemTotal:       16384 kB\nMemFree:         4096 kB\n",
+		"MemAvailable:    8192 kB\nMemFree:         4096 kB\n",
+	} {
+		info, err := parseMemInfo([]byte(data))
+		if err != nil {
+			t.Fatalf("parseMemInfo() error = %v", err)
+		}
+		if _, err := hostMemUsage(info); err == nil {
+			t.Errorf("hostMemUsage() of %q succeeded, want error", data)
+		}
+	}
+}
+
+func BenchmarkParseMemInfo(b *testing.B) {
+	data := readMemInfoFixture(b)
+	b.ReportAllocs()
+	b.ResetTimer()
+
+	for range b.N {
+		if _, err := parseMemInfo(data); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+// BenchmarkGetMemUsage measures GetMemUsage without reading /proc/meminfo, which is dominated by the kernel.
+func BenchmarkGetMemUsage(b *testing.B) {
+	data := readMemInfoFixture(b)
+	b.ReportAllocs()
+	b.ResetTimer()
+
+	for range b.N {
+		info, err := parseMemInfo(data)
+		if err != nil {
+			b.Fatal(err)
+		}
+		if _, err := hostMemUsage(info); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
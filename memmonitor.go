@@ -0,0 +1,362 @@
+package resourceutil
+
+import (
+	"fmt"
+	"log/slog"
+	"sync"
+	"time"
+)
+
+const (
+	defaultMemSampleInterval = time.Second
+	defaultMemWindow         = 60
+)
+
+// MemUsageSample represents a single memory usage measurement.
+// Fields:
+//   - Timestamp (time.Time): The time the measurement was taken.
+//   - Usage (MemUsage): The memory usage at that time.
+type MemUsageSample struct {
+	Timestamp time.Time
+	Usage     MemUsage
+}
+
+// MemUsageStats represents statistics of the used memory over the measurement window.
+// Fields:
+//   - Samples (int): The number of measurements the statistics cover.
+//   - AvgUsedGB (float64): The average used memory in gigabytes.
+//   - MinUsedGB (float64): The lowest used memory in gigabytes.
+//   - MaxUsedGB (float64): The highest used memory in gigabytes.
+//   - AvgUsedPercent (float64): The average percentage of memory in use.
+//   - MinUsedPercent (float64): The lowest percentage of memory in use.
+//   - MaxUsedPercent (float64): The highest percentage of memory in use.
+type MemUsageStats struct {
+	Samples        int
+	AvgUsedGB      float64
+	MinUsedGB      float64
+	MaxUsedGB      float64
+	AvgUsedPercent float64
+	MinUsedPercent float64
+	MaxUsedPercent float64
+}
+
+// memMeasureOptions holds the settings used by the memory measurement loop.
+type memMeasureOptions struct {
+	sampleInterval time.Duration
+	window         int
+	usage          []MemUsageOption
+}
+
+// MemMeasureOption configures StartMemMeasuring and NewMemMonitor.
+type MemMeasureOption func(*memMeasureOptions)
+
+// WithMemSampleInterval sets the time between memory usage measurements, default 1 s.
+func WithMemSampleInterval(interval time.Duration) MemMeasureOption {
+	return func(o *memMeasureOptions) {
+		o.sampleInterval = interval
+	}
+}
+
+// WithMemWindow sets the number of measurements kept for the history and statistics, default 60.
+func WithMemWindow(samples int) MemMeasureOption {
+	return func(o *memMeasureOptions) {
+		o.window = samples
+	}
+}
+
+// WithMemUsageOptions sets the options passed to GetMemUsage for every measurement, e.g. WithCgroupMem
+// to monitor the memory of the cgroup of the calling process.
+func WithMemUsageOptions(opts ...MemUsageOption) MemMeasureOption {
+	return func(o *memMeasureOptions) {
+		o.usage = opts
+	}
+}
+
+// newMemMeasureOptions applies opts over the defaults. Invalid options are logged and replaced by their defaults.
+func newMemMeasureOptions(opts []MemMeasureOption) memMeasureOptions {
+	options := memMeasureOptions{
+		sampleInterval: defaultMemSampleInterval,
+		window:         defaultMemWindow,
+	}
+	for _, opt := range opts {
+		opt(&options)
+	}
+	if options.sampleInterval <= 0 {
+		slog.Warn("Invalid memory sample interval, using default", slog.Duration("sample_interval", options.sampleInterval))
+		options.sampleInterval = defaultMemSampleInterval
+	}
+	if options.window <= 0 {
+		slog.Warn("Invalid memory measurement window, using default", slog.Int("window", options.window))
+		options.window = defaultMemWindow
+	}
+
+	return options
+}
+
+// MemMonitor measures the memory usage in the background and keeps the measurements of a sliding window.
+// The zero value is not usable, create monitors with NewMemMonitor.
+type MemMonitor struct {
+	// mu guards the running state of the measurement loop.
+	mu      sync.Mutex
+	running bool
+	options memMeasureOptions
+	stop    chan struct{}
+
+	// samplesMu guards the measurements of the window, newest last.
+	samplesMu sync.Mutex
+	samples   *ringBuffer[MemUsageSample]
+
+	// subsMu guards the subscribers to new measurements.
+	subsMu      sync.Mutex
+	subscribers map[int]chan MemUsageSample
+	nextSubID   int
+}
+
+// defaultMemMonitor is the monitor used by the package-level functions such as StartMemMeasuring.
+var defaultMemMonitor = NewMemMonitor()
+
+// NewMemMonitor creates a memory monitor with the given options. The monitor does not measure until Start is called.
+// Invalid options are logged and replaced by their defaults.
+func NewMemMonitor(opts ...MemMeasureOption) *MemMonitor {
+	return &MemMonitor{options: newMemMeasureOptions(opts)}
+}
+
+// Start starts the goroutine that measures the memory usage. The first measurement is taken immediately.
+func (m *MemMonitor) Start() {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.start(m.options)
+}
+
+// start starts measuring with the given options. The caller must hold m.mu.
+func (m *MemMonitor) start(options memMeasureOptions) {
+	if m.running {
+		slog.Warn("Unable to start memory measurement as it is already started")
+		return
+	}
+	m.running = true
+	m.options = options
+
+	// Discard measurements from a previous run
+	m.samplesMu.Lock()
+	m.samples = newRingBuffer[MemUsageSample](options.window)
+	m.samplesMu.Unlock()
+
+	m.stop = make(chan struct{})
+	go m.measureLoop(m.stop, options)
+}
+
+// Stop stops the goroutine that measures the memory usage. The monitor can be started again with Start.
+func (m *MemMonitor) Stop() {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if !m.running {
+		slog.Warn("Unable to stop memory measurement as it is not started")
+		return
+	}
+	m.running = false
+	close(m.stop)
+}
+
+// measureLoop measures the memory usage every sample interval until stop is closed.
+// Failed measurements are logged and skipped.
+func (m *MemMonitor) measureLoop(stop <-chan struct{}, options memMeasureOptions) {
+	ticker := time.NewTicker(options.sampleInterval)
+	defer ticker.Stop()
+
+	for {
+		usage, err := GetMemUsage(options.usage...)
+		if err != nil {
+			slog.Error("Failed to measure memory usage", slog.Any("error", err))
+		} else {
+			sample := MemUsageSample{Timestamp: time.Now(), Usage: usage}
+
+			// Holding mu ensures the sample is not pushed into the buffer of a run started after stopping
+			m.mu.Lock()
+			select {
+			case <-stop:
+				m.mu.Unlock()
+				return
+			default:
+			}
+			m.samplesMu.Lock()
+			m.samples.push(sample)
+			m.samplesMu.Unlock()
+			m.mu.Unlock()
+			slog.Debug("Measured memory usage", slog.Float64("used_percent", usage.UsedPercent))
+
+			m.publish(sample)
+		}
+
+		select {
+		case <-stop:
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// Usage retrieves the latest memory usage measurement.
+// Throws an error if the monitor has not started or no measurement has completed yet.
+func (m *MemMonitor) Usage() (MemUsage, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if !m.running {
+		return MemUsage{}, fmt.Errorf("memory measurement loop has not started, start measurement before trying to read usage")
+	}
+
+	m.samplesMu.Lock()
+	defer m.samplesMu.Unlock()
+
+	if m.samples.len() == 0 {
+		return MemUsage{}, fmt.Errorf("no memory usage measurement has completed yet")
+	}
+
+	return m.samples.newest(0).Usage, nil
+}
+
+// History retrieves the measurements in the window, oldest first.
+// Throws an error if the monitor has not started.
+func (m *MemMonitor) History() ([]MemUsageSample, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if !m.running {
+		return nil, fmt.Errorf("memory measurement loop has not started, start measurement before trying to read usage history")
+	}
+
+	m.samplesMu.Lock()
+	defer m.samplesMu.Unlock()
+
+	return m.samples.oldestFirst(m.samples.len()), nil
+}
+
+// Stats calculates the average, minimum and maximum of the used memory over the measurements in the window.
+// Throws an error if the monitor has not started or no measurement has completed yet.
+func (m *MemMonitor) Stats() (MemUsageStats, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if !m.running {
+		return MemUsageStats{}, fmt.Errorf("memory measurement loop has not started, start measurement before trying to read usage statistics")
+	}
+
+	m.samplesMu.Lock()
+	defer m.samplesMu.Unlock()
+
+	n := m.samples.len()
+	if n == 0 {
+		return MemUsageStats{}, fmt.Errorf("no memory usage measurement has completed yet")
+	}
+
+	newest := m.samples.newest(0).Usage
+	stats := MemUsageStats{
+		Samples:        n,
+		MinUsedGB:      newest.UsedGB,
+		MaxUsedGB:      newest.UsedGB,
+		MinUsedPercent: newest.UsedPercent,
+		MaxUsedPercent: newest.UsedPercent,
+	}
+	for i := range n {
+		usage := m.samples.newest(i).Usage
+		stats.AvgUsedGB += usage.UsedGB
+		stats.AvgUsedPercent += usage.UsedPercent
+		stats.MinUsedGB = min(stats.MinUsedGB, usage.UsedGB)
+		stats.MaxUsedGB = max(stats.MaxUsedGB, usage.UsedGB)
+		stats.MinUsedPercent = min(stats.MinUsedPercent, usage.UsedPercent)
+		stats.MaxUsedPercent = max(stats.MaxUsedPercent, usage.UsedPercent)
+	}
+	stats.AvgUsedGB /= float64(n)
+	stats.AvgUsedPercent /= float64(n)
+
+	return stats, nil
+}
+
+// Subscribe returns a channel that receives each new measurement as it completes, along with a function
+// that cancels the subscription and closes the channel. Measurements are dropped for a subscriber whose
+// buffer is full, so a slow consumer never stalls the measurement loop. The subscription survives Stop and Start.
+func (m *MemMonitor) Subscribe(buffer int) (<-chan MemUsageSample, func()) {
+	if buffer < 0 {
+		slog.Warn("Invalid memory usage subscription buffer, using unbuffered", slog.Int("buffer", buffer))
+		buffer = 0
+	}
+	updates := make(chan MemUsageSample, buffer)
+
+	m.subsMu.Lock()
+	if m.subscribers == nil {
+		m.subscribers = make(map[int]chan MemUsageSample)
+	}
+	id := m.nextSubID
+	m.nextSubID++
+	m.subscribers[id] = updates
+	m.subsMu.Unlock()
+
+	cancel := func() {
+		m.subsMu.Lock()
+		defer m.subsMu.Unlock()
+		if _, ok := m.subscribers[id]; !ok {
+			return
+		}
+		delete(m.subscribers, id)
+		close(updates)
+	}
+
+	return updates, cancel
+}
+
+// publish sends a new measurement to all subscribers without blocking.
+func (m *MemMonitor) publish(sample MemUsageSample) {
+	m.subsMu.Lock()
+	defer m.subsMu.Unlock()
+
+	for id, updates := range m.subscribers {
+		select {
+		case updates <- sample:
+		default:
+			slog.Debug("Dropped memory usage update as subscriber is not keeping up", slog.Int("subscriber", id))
+		}
+	}
+}
+
+// StartMemMeasuring starts the goroutine that measures the memory usage in the background.
+// Invalid options are logged and replaced by their defaults.
+func StartMemMeasuring(opts ...MemMeasureOption) {
+	options := newMemMeasureOptions(opts)
+
+	defaultMemMonitor.mu.Lock()
+	defer defaultMemMonitor.mu.Unlock()
+	defaultMemMonitor.start(options)
+}
+
+// StopMemMeasuring stops the goroutine that measures the memory usage.
+// The measurement can be started again with StartMemMeasuring.
+func StopMemMeasuring() {
+	defaultMemMonitor.Stop()
+}
+
+// GetMeasuredMemUsage retrieves the latest memory usage measurement of the background measurement loop.
+// Throws an error if the measurement loop has not started or no measurement has completed yet.
+func GetMeasuredMemUsage() (MemUsage, error) {
+	return defaultMemMonitor.Usage()
+}
+
+// GetMemUsageHistory retrieves the measurements in the window of the background measurement loop, oldest first.
+// Throws an error if the measurement loop has not started.
+func GetMemUsageHistory() ([]MemUsageSample, error) {
+	return defaultMemMonitor.History()
+}
+
+// GetMemUsageStats calculates the average, minimum and maximum of the used memory over the window of
+// the background measurement loop. Throws an error if the measurement loop has not started or no
+// measurement has completed yet.
+func GetMemUsageStats() (MemUsageStats, error) {
+	return defaultMemMonitor.Stats()
+}
+
+// SubscribeMemUsage returns a channel that receives each new measurement of the background measurement loop,
+// along with a function that cancels the subscription and closes the channel.
+// Measurements are dropped while the buffer is full. The channel stays open across StopMemMeasuring.
+func SubscribeMemUsage(buffer int) (<-chan MemUsageSample, func()) {
+	return defaultMemMonitor.Subscribe(buffer)
+}
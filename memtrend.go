@@ -0,0 +1,169 @@
+package resourceutil
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"time"
+)
+
+// defaultTrendWindow is the default number of RSS samples the trend is fitted over.
+const defaultTrendWindow = 60
+
+// RSSTrend represents the growth of the resident memory of a process over the trend window.
+// Fields:
+//   - Timestamp (time.Time): The time of the latest sample.
+//   - Samples (int): The number of samples in the window.
+//   - CurrentGB (float64): The latest resident set size in gigabytes.
+//   - GrowthGB (float64): The change of the resident set size from the oldest to the latest sample in gigabytes.
+//   - SlopeGBPerHour (float64): The growth rate from a linear regression over the window in gigabytes per hour,
+//     0 until there are two samples.
+type RSSTrend struct {
+	Timestamp      time.Time
+	Samples        int
+	CurrentGB      float64
+	GrowthGB       float64
+	SlopeGBPerHour float64
+}
+
+// rssTrendOptions holds the settings used by WatchRSSTrend.
+type rssTrendOptions struct {
+	window        int
+	alertRate     float64
+	alertSustain  time.Duration
+	alertCallback func(RSSTrend)
+}
+
+// RSSTrendOption configures WatchRSSTrend.
+type RSSTrendOption func(*rssTrendOptions)
+
+// WithTrendWindow sets the number of samples the trend is fitted over, default 60.
+func WithTrendWindow(samples int) RSSTrendOption {
+	return func(o *rssTrendOptions) {
+		o.window = samples
+	}
+}
+
+// WithGrowthAlert calls fn once the slope of the trend has stayed above gbPerHour for at least the sustained
+// duration. It fires again only after the slope has dropped to or below the rate and risen above it for another
+// sustained period. fn runs on the sampling goroutine, so it should not block.
+func WithGrowthAlert(gbPerHour float64, sustained time.Duration, fn func(RSSTrend)) RSSTrendOption {
+	return func(o *rssTrendOptions) {
+		o.alertRate = gbPerHour
+		o.alertSustain = sustained
+		o.alertCallback = fn
+	}
+}
+
+// rssSample is a resident set size sample of a process.
+type rssSample struct {
+	timestamp time.Time
+	rssGB     float64
+}
+
+// WatchRSSTrend samples the resident set size of a process every interval and sends the trend over the window
+// on the returned channel after each sample, for early warnings of memory leaks in long-running services.
+// Trends are dropped if the receiver falls behind. The channel is closed when ctx is cancelled or the
+// process exits.
+func WatchRSSTrend(ctx context.Context, pid int, interval time.Duration, opts ...RSSTrendOption) <-chan RSSTrend {
+	options := rssTrendOptions{window: defaultTrendWindow}
+	for _, opt := range opts {
+		opt(&options)
+	}
+	if options.window < 2 {
+		slog.Warn("Invalid RSS trend window, using default", slog.Int("window", options.window))
+		options.window = defaultTrendWindow
+	}
+
+	trends := make(chan RSSTrend, 1)
+
+	go func() {
+		defer close(trends)
+
+		if interval <= 0 {
+			slog.Error("Unable to watch RSS trend with non-positive interval", slog.Duration("interval", interval))
+			return
+		}
+
+		samples := newRingBuffer[rssSample](options.window)
+		var aboveSince time.Time
+		alerted := false
+
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+
+		for {
+			usage, err := GetProcessMemUsage(pid)
+			if errors.Is(err, ErrProcessNotFound) {
+				slog.Info("Stopped watching RSS trend as the process exited", slog.Int("pid", pid))
+				return
+			}
+			if err != nil {
+				slog.Warn("Failed to get process memory usage for RSS trend", slog.Int("pid", pid), slog.Any("error", err))
+			} else {
+				samples.push(rssSample{timestamp: time.Now(), rssGB: usage.RSSGB})
+				trend := rssTrend(samples.oldestFirst(samples.len()))
+
+				if options.alertCallback != nil {
+					if trend.Samples < 2 || trend.SlopeGBPerHour <= options.alertRate {
+						aboveSince = time.Time{}
+						alerted = false
+					} else {
+						if aboveSince.IsZero() {
+							aboveSince = trend.Timestamp
+						}
+						if !alerted && trend.Timestamp.Sub(aboveSince) >= options.alertSustain {
+							alerted = true
+							slog.Warn("Process memory growth stayed above rate", slog.Int("pid", pid), slog.Float64("slope_GB_per_hour", trend.SlopeGBPerHour))
+							options.alertCallback(trend)
+						}
+					}
+				}
+
+				select {
+				case trends <- trend:
+				default:
+					slog.Debug("Dropped RSS trend as receiver is not keeping up", slog.Time("timestamp", trend.Timestamp))
+				}
+			}
+
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+			}
+		}
+	}()
+
+	return trends
+}
+
+// rssTrend fits a least squares line through the samples, ordered oldest first.
+func rssTrend(samples []rssSample) RSSTrend {
+	first, last := samples[0], samples[len(samples)-1]
+	trend := RSSTrend{
+		Timestamp: last.timestamp,
+		Samples:   len(samples),
+		CurrentGB: last.rssGB,
+		GrowthGB:  last.rssGB - first.rssGB,
+	}
+	if len(samples) < 2 {
+		return trend
+	}
+
+	n := float64(len(samples))
+	var sumX, sumY, sumXY, sumXX float64
+	for _, sample := range samples {
+		x := sample.timestamp.Sub(first.timestamp).Hours()
+		sumX += x
+		sumY += sample.rssGB
+		sumXY += x * sample.rssGB
+		sumXX += x * x
+	}
+
+	if denominator := n*sumXX - sumX*sumX; denominator > 0 {
+		trend.SlopeGBPerHour = (n*sumXY - sumX*sumY) / denominator
+	}
+
+	return trend
+}
@@ -0,0 +1,7 @@
+module github.com/anvnamn/resourceutil/metricsserver
+
+go 1.23.1
+
+// To develop against an unreleased resourceutil, create an untracked workspace in the repository
+// root with: go work init . ./metricsserver
+require github.com/anvnamn/resourceutil v0.1.0
@@ -0,0 +1,62 @@
+// Package metricsserver provides a ready-made HTTP handler that streams resourceutil system snapshots
+// to connected clients as server-sent events.
+//
+// It is a separate module so that the core resourceutil package stays free of networking code.
+package metricsserver
+
+import (
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"time"
+
+	"github.com/anvnamn/resourceutil"
+)
+
+// SSEHandler streams a resourceutil.SystemSnapshot as a JSON encoded server-sent event every interval.
+// Each connected client gets its own snapshot stream, which is stopped when the client disconnects.
+type SSEHandler struct {
+	interval time.Duration
+	opts     []resourceutil.SnapshotOption
+}
+
+// NewSSEHandler creates an SSEHandler that pushes snapshots at the given interval.
+// The options are passed on to resourceutil.StreamSnapshots.
+func NewSSEHandler(interval time.Duration, opts ...resourceutil.SnapshotOption) *SSEHandler {
+	return &SSEHandler{
+		interval: interval,
+		opts:     opts,
+	}
+}
+
+// ServeHTTP implements http.Handler.
+func (h *SSEHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	flusher, ok := w.(http.Flusher)
+	if !ok {
+		http.Error(w, "streaming not supported", http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "text/event-stream")
+	w.Header().Set("Cache-Control", "no-cache")
+	w.Header().Set("Connection", "keep-alive")
+	w.WriteHeader(http.StatusOK)
+	flusher.Flush()
+
+	slog.Debug("Client connected to snapshot stream", slog.String("remote_addr", r.RemoteAddr))
+
+	for snapshot := range resourceutil.StreamSnapshots(r.Context(), h.interval, h.opts...) {
+		data, err := json.Marshal(snapshot)
+		if err != nil {
+			slog.Error("Failed to encode snapshot", slog.Any("error", err))
+			continue
+		}
+
+		if _, err := fmt.Fprintf(w, "event: snapshot\ndata: %s\n\n", data); err != nil {
+			slog.Debug("Client disconnected from snapshot stream", slog.String("remote_addr", r.RemoteAddr), slog.Any("error", err))
+			return
+		}
+		flusher.Flush()
+	}
+}
@@ -0,0 +1,272 @@
+package resourceutil
+
+import (
+	"fmt"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"strconv"
+	"time"
+)
+
+// netClassDir is the sysfs directory where the kernel exposes network interfaces.
+const netClassDir = "/sys/class/net"
+
+// Interface flags from <linux/if.h>.
+const (
+	iffUp       = 0x1
+	iffLoopback = 0x8
+)
+
+// LinkInfo represents the link state of a network interface.
+// Fields:
+//   - Name (string): The interface name, e.g. "eth0".
+//   - Up (bool): Whether the interface is administratively up.
+//   - OperState (string): The operational state reported by the kernel, e.g. "up", "down" or "unknown".
+//   - Loopback (bool): Whether the interface is a loopback interface.
+//   - SpeedMbps (int): The link speed in Mbit/s, -1 if unknown or the link is down.
+//   - MTU (int): The maximum transmission unit in bytes.
+type LinkInfo struct {
+	Name      string
+	Up        bool
+	OperState string
+	Loopback  bool
+	SpeedMbps int
+	MTU       int
+}
+
+// Throughput represents the receive and transmit rate of a network interface.
+// Fields:
+//   - RxBytesPerSec (float64): The received bytes per second over the measured interval.
+//   - TxBytesPerSec (float64): The transmitted bytes per second over the measured interval.
+type Throughput struct {
+	RxBytesPerSec float64
+	TxBytesPerSec float64
+}
+
+// InterfaceSummary combines the link state and throughput of a network interface.
+type InterfaceSummary struct {
+	LinkInfo
+	Throughput
+}
+
+// NetworkSummary represents the state of all network interfaces on the system.
+// Fields:
+//   - Interfaces ([]InterfaceSummary): The summary of each included interface, ordered by name.
+type NetworkSummary struct {
+	Interfaces []InterfaceSummary
+}
+
+// networkSummaryOptions holds the settings used by GetNetworkSummary.
+type networkSummaryOptions struct {
+	includeLoopback bool
+	includeDown     bool
+	interval        time.Duration
+}
+
+// NetworkSummaryOption configures GetNetworkSummary.
+type NetworkSummaryOption func(*networkSummaryOptions)
+
+// WithLoopback includes loopback interfaces in the network summary.
+func WithLoopback() NetworkSummaryOption {
+	return func(o *networkSummaryOptions) {
+		o.includeLoopback = true
+	}
+}
+
+// WithDownInterfaces includes interfaces that are administratively down in the network summary.
+func WithDownInterfaces() NetworkSummaryOption {
+	return func(o *networkSummaryOptions) {
+		o.includeDown = true
+	}
+}
+
+// WithThroughputInterval sets the interval over which throughput is measured, default 1 second.
+func WithThroughputInterval(interval time.Duration) NetworkSummaryOption {
+	return func(o *networkSummaryOptions) {
+		o.interval = interval
+	}
+}
+
+// GetLinkInfo retrieves the link state, speed and MTU of a network interface.
+func GetLinkInfo(iface string) (LinkInfo, error) {
+	if iface == "" {
+		return LinkInfo{}, fmt.Errorf("interface name cannot be empty")
+	}
+
+	dir := filepath.Join(netClassDir, iface)
+
+	flagsStr, err := stringFromFile(filepath.Join(dir, "flags"))
+	if err != nil {
+		return LinkInfo{}, fmt.Errorf("failed to get flags for interface %s: %w", iface, err)
+	}
+	flags, err := strconv.ParseUint(flagsStr, 0, 32)
+	if err != nil {
+		return LinkInfo{}, fmt.Errorf("failed to parse flags for interface %s: %w", iface, err)
+	}
+
+	operState, err := stringFromFile(filepath.Join(dir, "operstate"))
+	if err != nil {
+		return LinkInfo{}, fmt.Errorf("failed to get operstate for interface %s: %w", iface, err)
+	}
+
+	mtu, err := intFromFile(filepath.Join(dir, "mtu"))
+	if err != nil {
+		return LinkInfo{}, fmt.Errorf("failed to get MTU for interface %s: %w", iface, err)
+	}
+
+	// Reading speed fails with EINVAL for virtual interfaces and links that are down.
+	speed, err := intFromFile(filepath.Join(dir, "speed"))
+	if err != nil || speed < 0 {
+		speed = -1
+	}
+
+	return LinkInfo{
+		Name:      iface,
+		Up:        flags&iffUp != 0,
+		OperState: operState,
+		Loopback:  flags&iffLoopback != 0,
+		SpeedMbps: speed,
+		MTU:       mtu,
+	}, nil
+}
+
+// GetInterfaceThroughput measures the receive and transmit rate of a network interface over the given interval.
+// This call blocks for the duration of the interval.
+func GetInterfaceThroughput(iface string, interval time.Duration) (Throughput, error) {
+	if iface == "" {
+		return Throughput{}, fmt.Errorf("interface name cannot be empty")
+	}
+	if interval <= 0 {
+		return Throughput{}, fmt.Errorf("interval must be positive, got %s", interval)
+	}
+
+	rx1, tx1, err := readInterfaceCounters(iface)
+	if err != nil {
+		return Throughput{}, err
+	}
+	start := time.Now()
+
+	time.Sleep(interval)
+
+	rx2, tx2, err := readInterfaceCounters(iface)
+	if err != nil {
+		return Throughput{}, err
+	}
+
+	return calculateThroughput(rx1, tx1, rx2, tx2, time.Since(start)), nil
+}
+
+// GetNetworkSummary retrieves the link state and throughput of every non-loopback interface that is up.
+// Loopback and down interfaces can be included with WithLoopback and WithDownInterfaces.
+// All interfaces are measured over the same interval, so the call blocks once for its duration.
+func GetNetworkSummary(opts ...NetworkSummaryOption) (NetworkSummary, error) {
+	options := networkSummaryOptions{interval: time.Second}
+	for _, opt := range opts {
+		opt(&options)
+	}
+	if options.interval <= 0 {
+		return NetworkSummary{}, fmt.Errorf("interval must be positive, got %s", options.interval)
+	}
+
+	entries, err := os.ReadDir(netClassDir)
+	if err != nil {
+		return NetworkSummary{}, fmt.Errorf("failed to list network interfaces in %s: %w", netClassDir, err)
+	}
+
+	var links []LinkInfo
+	for _, entry := range entries {
+		link, err := GetLinkInfo(entry.Name())
+		if err != nil {
+			slog.Warn("Skipping network interface", slog.String("interface", entry.Name()), slog.Any("error", err))
+			continue
+		}
+		if link.Loopback && !options.includeLoopback {
+			continue
+		}
+		if !link.Up && !options.includeDown {
+			continue
+		}
+		links = append(links, link)
+	}
+
+	type counters struct {
+		rx, tx uint64
+		ok     bool
+	}
+	first := make([]counters, len(links))
+	for i, link := range links {
+		first[i].rx, first[i].tx, err = readInterfaceCounters(link.Name)
+		if err != nil {
+			// The interface may have disappeared since it was listed.
+			slog.Warn("Skipping network interface", slog.String("interface", link.Name), slog.Any("error", err))
+			continue
+		}
+		first[i].ok = true
+	}
+	start := time.Now()
+
+	time.Sleep(options.interval)
+	// Reading the counters takes microseconds, so all interfaces share the elapsed time
+	elapsed := time.Since(start)
+
+	summary := NetworkSummary{Interfaces: make([]InterfaceSummary, 0, len(links))}
+	for i, link := range links {
+		if !first[i].ok {
+			continue
+		}
+		rx, tx, err := readInterfaceCounters(link.Name)
+		if err != nil {
+			// The interface may have disappeared during the interval.
+			slog.Warn("Skipping network interface", slog.String("interface", link.Name), slog.Any("error", err))
+			continue
+		}
+		summary.Interfaces = append(summary.Interfaces, InterfaceSummary{
+			LinkInfo:   link,
+			Throughput: calculateThroughput(first[i].rx, first[i].tx, rx, tx, elapsed),
+		})
+	}
+
+	slog.Debug("Got network summary", slog.Any("network_summary", summary))
+
+	return summary, nil
+}
+
+// readInterfaceCounters reads the total received and transmitted bytes of a network interface.
+func readInterfaceCounters(iface string) (rx, tx uint64, err error) {
+	dir := filepath.Join(netClassDir, iface, "statistics")
+
+	rxStr, err := stringFromFile(filepath.Join(dir, "rx_bytes"))
+	if err != nil {
+		return 0, 0, fmt.Errorf("failed to get rx_bytes for interface %s: %w", iface, err)
+	}
+	rx, err = strconv.ParseUint(rxStr, 10, 64)
+	if err != nil {
+		return 0, 0, fmt.Errorf("failed to parse rx_bytes for interface %s: %w", iface, err)
+	}
+
+	txStr, err := stringFromFile(filepath.Join(dir, "tx_bytes"))
+	if err != nil {
+		return 0, 0, fmt.Errorf("failed to get tx_bytes for interface %s: %w", iface, err)
+	}
+	tx, err = strconv.ParseUint(txStr, 10, 64)
+	if err != nil {
+		return 0, 0, fmt.Errorf("failed to parse tx_bytes for interface %s: %w", iface, err)
+	}
+
+	return rx, tx, nil
+}
+
+// calculateThroughput converts two counter snapshots taken elapsed apart into rates.
+// Counters that went backwards (e.g. after a driver reset) result in a zero rate.
+func calculateThroughput(rx1, tx1, rx2, tx2 uint64, elapsed time.Duration) Throughput {
+	var throughput Throughput
+	seconds := elapsed.Seconds()
+	if rx2 >= rx1 {
+		throughput.RxBytesPerSec = float64(rx2-rx1) / seconds
+	}
+	if tx2 >= tx1 {
+		throughput.TxBytesPerSec = float64(tx2-tx1) / seconds
+	}
+	return throughput
+}
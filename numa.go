@@ -0,0 +1,183 @@
+package resourceutil
+
+import (
+	"errors"
+	"fmt"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"slices"
+	"strconv"
+	"strings"
+)
+
+// nodeSysDir is the sysfs directory where the kernel exposes NUMA nodes.
+const nodeSysDir = "/sys/devices/system/node"
+
+// ErrNUMAUnsupported is returned when the kernel does not expose NUMA nodes.
+var ErrNUMAUnsupported = errors.New("NUMA nodes not exposed by the kernel")
+
+// NUMANode represents the CPUs, memory and distances of a NUMA node.
+// Fields:
+//   - ID (int): The node ID.
+//   - CPUs ([]int): The IDs of the CPUs belonging to the node, empty for memory-only nodes.
+//   - MemTotalGB (float64): The total memory of the node in gigabytes.
+//   - MemFreeGB (float64): The free memory of the node in gigabytes.
+//   - Distances (map[int]int): The relative access distance to each online node keyed by node ID, 10 being local.
+type NUMANode struct {
+	ID         int
+	CPUs       []int
+	MemTotalGB float64
+	MemFreeGB  float64
+	Distances  map[int]int
+}
+
+// GetNUMANodes retrieves the CPU list, memory and distances of every online NUMA node, ordered by node ID.
+// Returns ErrNUMAUnsupported if the kernel does not expose NUMA nodes.
+func GetNUMANodes() ([]NUMANode, error) {
+	nodeIDs, err := onlineNUMANodes()
+	if err != nil {
+		return nil, err
+	}
+
+	const bytesPerGB = 1024 * 1024 * 1024
+	nodes := make([]NUMANode, 0, len(nodeIDs))
+	for _, id := range nodeIDs {
+		dir := filepath.Join(nodeSysDir, fmt.Sprintf("node%d", id))
+
+		cpuList, err := stringFromFile(filepath.Join(dir, "cpulist"))
+		if err != nil {
+			return nil, fmt.Errorf("failed to get CPU list for node %d: %w", id, err)
+		}
+		cpus, err := parseCPUList(cpuList)
+		if err != nil {
+			return nil, fmt.Errorf("failed to get CPU list for node %d: %w", id, err)
+		}
+
+		data, err := readNodeMemInfo(id)
+		if err != nil {
+			return nil, err
+		}
+		info, err := parseMemInfo(data)
+		if err != nil {
+			return nil, fmt.Errorf("failed to get memory info for node %d: %w", id, err)
+		}
+		memTotal := float64(info.MemTotal) / bytesPerGB
+		memFree := float64(info.MemFree) / bytesPerGB
+
+		distanceStr, err := stringFromFile(filepath.Join(dir, "distance"))
+		if err != nil {
+			return nil, fmt.Errorf("failed to get distances for node %d: %w", id, err)
+		}
+		// The distance file lists the distance to each online node in order of node ID.
+		distanceFields := strings.Fields(distanceStr)
+		if len(distanceFields) != len(nodeIDs) {
+			return nil, fmt.Errorf("unexpected number of distances for node %d: %s", id, distanceStr)
+		}
+		distances := make(map[int]int, len(nodeIDs))
+		for i, field := range distanceFields {
+			distance, err := strconv.Atoi(field)
+			if err != nil {
+				return nil, fmt.Errorf("failed to parse distance for node %d: %w", id, err)
+			}
+			distances[nodeIDs[i]] = distance
+		}
+
+		nodes = append(nodes, NUMANode{
+			ID:         id,
+			CPUs:       cpus,
+			MemTotalGB: memTotal,
+			MemFreeGB:  memFree,
+			Distances:  distances,
+		})
+	}
+
+	slog.Debug("Got NUMA nodes", slog.Any("numa_nodes", nodes))
+
+	return nodes, nil
+}
+
+// NUMAMemUsage represents the memory usage of a NUMA node.
+// Fields:
+//   - Node (int): The NUMA node ID.
+//   - TotalGB (float64): The memory of the node in gigabytes.
+//   - FreeGB (float64): The unused memory of the node in gigabytes.
+//   - UsedGB (float64): The memory of the node in use, including page cache, in gigabytes.
+//   - UsedPercent (float64): The percentage of the memory of the node in use.
+//   - FilePagesGB (float64): The page cache allocated on the node in gigabytes.
+//   - AnonPagesGB (float64): The anonymous memory of processes allocated on the node in gigabytes.
+//
+// Nodes have no MemAvailable estimate, so a node with little free memory may still have plenty of reclaimable
+// page cache. A node running out of anonymous memory while others are free points to a NUMA imbalance.
+type NUMAMemUsage struct {
+	Node        int
+	TotalGB     float64
+	FreeGB      float64
+	UsedGB      float64
+	UsedPercent float64
+	FilePagesGB float64
+	AnonPagesGB float64
+}
+
+// GetNUMAMemUsage retrieves the memory usage of every online NUMA node from
+// /sys/devices/system/node/node*/meminfo, ordered by node ID.
+// Returns ErrNUMAUnsupported if the kernel does not expose NUMA nodes.
+func GetNUMAMemUsage() ([]NUMAMemUsage, error) {
+	nodeIDs, err := onlineNUMANodes()
+	if err != nil {
+		return nil, err
+	}
+
+	const bytesPerGB = 1024 * 1024 * 1024
+	usages := make([]NUMAMemUsage, 0, len(nodeIDs))
+	for _, id := range nodeIDs {
+		data, err := readNodeMemInfo(id)
+		if err != nil {
+			return nil, err
+		}
+		info, err := parseMemInfo(data)
+		if err != nil {
+			return nil, fmt.Errorf("failed to get memory info for node %d: %w", id, err)
+		}
+		if info.MemTotal == 0 {
+			// Memoryless nodes, e.g. with only CPUs
+			usages = append(usages, NUMAMemUsage{Node: id})
+			continue
+		}
+
+		used := info.MemTotal - min(info.MemFree, info.MemTotal)
+		usages = append(usages, NUMAMemUsage{
+			Node:        id,
+			TotalGB:     float64(info.MemTotal) / bytesPerGB,
+			FreeGB:      float64(info.MemFree) / bytesPerGB,
+			UsedGB:      float64(used) / bytesPerGB,
+			UsedPercent: 100 * float64(used) / float64(info.MemTotal),
+			// FilePages is only reported for nodes, so it is not a field of MemInfo
+			FilePagesGB: float64(info.Other["FilePages"]) / bytesPerGB,
+			AnonPagesGB: float64(info.AnonPages) / bytesPerGB,
+		})
+	}
+
+	slog.Debug("Got NUMA memory usage", slog.Any("numa_mem_usage", usages))
+
+	return usages, nil
+}
+
+// onlineNUMANodes returns the sorted IDs of the online NUMA nodes.
+func onlineNUMANodes() ([]int, error) {
+	online, err := stringFromFile(filepath.Join(nodeSysDir, "online"))
+	if err != nil {
+		if errors.Is(err, os.ErrNotExist) {
+			return nil, ErrNUMAUnsupported
+		}
+		return nil, err
+	}
+
+	nodeIDs, err := parseCPUList(online)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse online NUMA nodes: %w", err)
+	}
+	slices.Sort(nodeIDs)
+
+	return nodeIDs, nil
+}
@@ -0,0 +1,193 @@
+package resourceutil
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"os"
+	"strings"
+	"syscall"
+	"time"
+)
+
+// ErrOOMKillCountUnsupported is returned when /proc/vmstat has no oom_kill counter, which requires Linux 4.13 or later.
+var ErrOOMKillCountUnsupported = errors.New("oom_kill counter not available in /proc/vmstat")
+
+// maxOOMMessages caps the kernel log lines kept for a single OOM event.
+const maxOOMMessages = 100
+
+// OOMEvent represents one or more OOM kills by the kernel detected during a poll.
+// Fields:
+//   - Timestamp (time.Time): The time the kills were detected.
+//   - Kills (uint64): The number of processes killed since the previous poll.
+//   - Total (uint64): The number of OOM kills since boot.
+//   - Messages ([]string): The kernel log lines about the kills, such as "Killed process ...". Only set WithKernelLog.
+type OOMEvent struct {
+	Timestamp time.Time
+	Kills     uint64
+	Total     uint64
+	Messages  []string
+}
+
+// oomWatchOptions holds the settings used by WatchOOMKills.
+type oomWatchOptions struct {
+	kernelLog bool
+}
+
+// OOMWatchOption configures WatchOOMKills.
+type OOMWatchOption func(*oomWatchOptions)
+
+// WithKernelLog makes WatchOOMKills also follow /dev/kmsg and attach the kernel log lines about each kill to
+// its event, which name the killed process. Reading /dev/kmsg may require root or CAP_SYSLOG.
+func WithKernelLog() OOMWatchOption {
+	return func(o *oomWatchOptions) {
+		o.kernelLog = true
+	}
+}
+
+// WatchOOMKills polls the oom_kill counter of /proc/vmstat every interval and sends an OOMEvent on the returned
+// channel whenever the kernel OOM-killed a process, until ctx is cancelled, after which the channel is closed.
+// Kills that happened before the call are not reported.
+// Returns ErrOOMKillCountUnsupported if the kernel has no oom_kill counter.
+func WatchOOMKills(ctx context.Context, interval time.Duration, opts ...OOMWatchOption) (<-chan OOMEvent, error) {
+	var options oomWatchOptions
+	for _, opt := range opts {
+		opt(&options)
+	}
+
+	if interval <= 0 {
+		return nil, fmt.Errorf("interval must be positive, got %s", interval)
+	}
+
+	total, err := readOOMKillCount()
+	if err != nil {
+		return nil, err
+	}
+
+	var kmsg *os.File
+	if options.kernelLog {
+		if kmsg, err = openKernelLog(); err != nil {
+			return nil, err
+		}
+	}
+
+	events := make(chan OOMEvent, 16)
+
+	go func() {
+		defer close(events)
+		if kmsg != nil {
+			defer kmsg.Close()
+		}
+
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+
+		var messages []string
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+			}
+
+			if kmsg != nil {
+				messages = append(messages, readOOMMessages(kmsg)...)
+				// Drop the oldest lines if kills are logged without the counter moving, e.g. in cgroups
+				if len(messages) > maxOOMMessages {
+					messages = messages[len(messages)-maxOOMMessages:]
+				}
+			}
+
+			nextTotal, err := readOOMKillCount()
+			if err != nil {
+				slog.Warn("Failed to read OOM kill count", slog.Any("error", err))
+				continue
+			}
+			if nextTotal <= total {
+				continue
+			}
+
+			event := OOMEvent{
+				Timestamp: time.Now(),
+				Kills:     nextTotal - total,
+				Total:     nextTotal,
+				Messages:  messages,
+			}
+			total = nextTotal
+			messages = nil
+
+			slog.Warn("Detected OOM kill", slog.Uint64("kills", event.Kills), slog.Uint64("total", event.Total))
+
+			select {
+			case events <- event:
+			case <-ctx.Done():
+				return
+			}
+		}
+	}()
+
+	return events, nil
+}
+
+// readOOMKillCount reads the number of OOM kills since boot from /proc/vmstat.
+func readOOMKillCount() (uint64, error) {
+	counters, err := readVMStat()
+	if err != nil {
+		return 0, err
+	}
+
+	total, ok := counters["oom_kill"]
+	if !ok {
+		return 0, ErrOOMKillCountUnsupported
+	}
+
+	return total, nil
+}
+
+// openKernelLog opens /dev/kmsg for non-blocking reads of new records only.
+func openKernelLog() (*os.File, error) {
+	fd, err := syscall.Open("/dev/kmsg", syscall.O_RDONLY|syscall.O_NONBLOCK|syscall.O_CLOEXEC, 0)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open /dev/kmsg: %w", err)
+	}
+	// Skip the records logged before the watch started
+	if _, err := syscall.Seek(fd, 0, io.SeekEnd); err != nil {
+		syscall.Close(fd)
+		return nil, fmt.Errorf("failed to seek /dev/kmsg: %w", err)
+	}
+
+	return os.NewFile(uintptr(fd), "/dev/kmsg"), nil
+}
+
+// readOOMMessages reads the records available in /dev/kmsg and returns the messages about OOM kills.
+func readOOMMessages(kmsg *os.File) []string {
+	// Each read returns a single record, which the kernel limits to about 1 kB of text plus metadata
+	buf := make([]byte, 8192)
+
+	var messages []string
+	for {
+		n, err := syscall.Read(int(kmsg.Fd()), buf)
+		if err == syscall.EPIPE {
+			// Records were overwritten before they could be read, continue with the next available one
+			continue
+		}
+		if err != nil || n <= 0 {
+			// EAGAIN once all records have been read
+			return messages
+		}
+
+		// Format: priority,sequence,timestamp,flags;message
+		_, message, ok := strings.Cut(string(buf[:n]), ";")
+		if !ok {
+			continue
+		}
+		message, _, _ = strings.Cut(message, "\n")
+
+		if strings.Contains(message, "Out of memory") || strings.Contains(message, "oom-kill") ||
+			strings.Contains(message, "Killed process") || strings.Contains(message, "Memory cgroup out of memory") {
+			messages = append(messages, message)
+		}
+	}
+}
@@ -0,0 +1,90 @@
+package resourceutil
+
+import (
+	"fmt"
+	"log/slog"
+)
+
+// OvercommitMode is the virtual memory overcommit policy of the kernel, from vm.overcommit_memory.
+type OvercommitMode int
+
+const (
+	// OvercommitHeuristic refuses only obvious overcommits of address space, the kernel default.
+	OvercommitHeuristic OvercommitMode = 0
+	// OvercommitAlways never refuses an allocation.
+	OvercommitAlways OvercommitMode = 1
+	// OvercommitNever refuses allocations that would bring the commit charge above the commit limit.
+	OvercommitNever OvercommitMode = 2
+)
+
+// String returns the name of the overcommit mode.
+func (m OvercommitMode) String() string {
+	switch m {
+	case OvercommitHeuristic:
+		return "heuristic"
+	case OvercommitAlways:
+		return "always"
+	case OvercommitNever:
+		return "never"
+	default:
+		return fmt.Sprintf("unknown (%d)", int(m))
+	}
+}
+
+// CommitCharge represents the committed virtual memory of the system and the overcommit settings.
+// Fields:
+//   - CommitLimitGB (float64): The total memory that can be committed under strict overcommit in gigabytes.
+//   - CommittedGB (float64): The memory currently committed by all processes in gigabytes (Committed_AS).
+//   - CommittedPercent (float64): The committed memory as a percentage of the commit limit.
+//   - Mode (OvercommitMode): The overcommit policy from vm.overcommit_memory.
+//   - Ratio (int): The percentage of RAM counted towards the commit limit, from vm.overcommit_ratio.
+//   - LimitKB (uint64): The amount of RAM counted towards the commit limit in kB, from vm.overcommit_kbytes.
+//     When non-zero it is used instead of Ratio.
+//
+// Under OvercommitNever allocations fail once CommittedGB reaches CommitLimitGB, long before MemAvailable runs
+// out. With the other modes the commit limit is not enforced and CommittedPercent may exceed 100.
+type CommitCharge struct {
+	CommitLimitGB    float64
+	CommittedGB      float64
+	CommittedPercent float64
+	Mode             OvercommitMode
+	Ratio            int
+	LimitKB          uint64
+}
+
+// GetCommitCharge retrieves the commit limit and commit charge from /proc/meminfo
+// and the overcommit settings from /proc/sys/vm.
+func GetCommitCharge() (CommitCharge, error) {
+	info, err := GetMemInfo()
+	if err != nil {
+		return CommitCharge{}, err
+	}
+
+	mode, err := intFromFile("/proc/sys/vm/overcommit_memory")
+	if err != nil {
+		return CommitCharge{}, fmt.Errorf("failed to get overcommit mode: %w", err)
+	}
+	ratio, err := intFromFile("/proc/sys/vm/overcommit_ratio")
+	if err != nil {
+		return CommitCharge{}, fmt.Errorf("failed to get overcommit ratio: %w", err)
+	}
+	limitKB, err := uint64FromFile("/proc/sys/vm/overcommit_kbytes")
+	if err != nil {
+		return CommitCharge{}, fmt.Errorf("failed to get overcommit kbytes: %w", err)
+	}
+
+	const bytesPerGB = 1024 * 1024 * 1024
+	charge := CommitCharge{
+		CommitLimitGB: float64(info.CommitLimit) / bytesPerGB,
+		CommittedGB:   float64(info.CommittedAS) / bytesPerGB,
+		Mode:          OvercommitMode(mode),
+		Ratio:         ratio,
+		LimitKB:       limitKB,
+	}
+	if info.CommitLimit > 0 {
+		charge.CommittedPercent = 100 * float64(info.CommittedAS) / float64(info.CommitLimit)
+	}
+	slog.Debug("Got commit charge", slog.Any("commit_charge", charge))
+
+	return charge, nil
+}
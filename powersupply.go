@@ -0,0 +1,202 @@
+package resourceutil
+
+import (
+	"errors"
+	"fmt"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"time"
+)
+
+// PowerSupplyType is the kind of a power supply as reported in its sysfs type attribute.
+type PowerSupplyType string
+
+const (
+	PowerSupplyBattery  PowerSupplyType = "Battery"
+	PowerSupplyMains    PowerSupplyType = "Mains"
+	PowerSupplyUSB      PowerSupplyType = "USB"
+	PowerSupplyUPS      PowerSupplyType = "UPS"
+	PowerSupplyWireless PowerSupplyType = "Wireless"
+)
+
+// PowerSupply represents a power supply exposed in /sys/class/power_supply.
+// Fields:
+//   - Name (string): The sysfs name of the power supply, e.g. "BAT0" or "AC", as passed to the battery functions.
+//   - Type (PowerSupplyType): The kind of power supply.
+//   - Scope (string): "System" for supplies powering the machine, "Device" for batteries of peripherals such as
+//     wireless mice, empty if the driver does not report it.
+type PowerSupply struct {
+	Name  string
+	Type  PowerSupplyType
+	Scope string
+}
+
+// ListPowerSupplies retrieves the power supplies in /sys/class/power_supply, ordered by name.
+// Returns an empty slice on machines without any, such as most servers and virtual machines.
+func ListPowerSupplies() ([]PowerSupply, error) {
+	entries, err := os.ReadDir(powerSupplyDir)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return []PowerSupply{}, nil
+		}
+		return nil, fmt.Errorf("failed to list power supplies in %s: %w", powerSupplyDir, err)
+	}
+
+	supplies := make([]PowerSupply, 0, len(entries))
+	for _, entry := range entries {
+		dir := filepath.Join(powerSupplyDir, entry.Name())
+		supplyType, err := stringFromFile(filepath.Join(dir, "type"))
+		if err != nil {
+			slog.Warn("Skipping power supply without type", slog.String("name", entry.Name()), slog.Any("error", err))
+			continue
+		}
+		// The scope attribute is optional
+		scope, _ := stringFromFile(filepath.Join(dir, "scope"))
+
+		supplies = append(supplies, PowerSupply{
+			Name:  entry.Name(),
+			Type:  PowerSupplyType(supplyType),
+			Scope: scope,
+		})
+	}
+
+	slog.Debug("Got power supplies", slog.Any("power_supplies", supplies))
+
+	return supplies, nil
+}
+
+// ListBatteries retrieves the names of the batteries powering the system, ordered by name, e.g. ["BAT0", "BAT1"].
+// Batteries of peripherals are excluded. Returns an empty slice on machines without batteries.
+func ListBatteries() ([]string, error) {
+	supplies, err := ListPowerSupplies()
+	if err != nil {
+		return nil, err
+	}
+
+	batteries := []string{}
+	for _, supply := range supplies {
+		if supply.Type == PowerSupplyBattery && supply.Scope != "Device" {
+			batteries = append(batteries, supply.Name)
+		}
+	}
+
+	return batteries, nil
+}
+
+// ErrNoBattery is returned when the system has no battery.
+var ErrNoBattery = errors.New("no battery found")
+
+// GetCombinedBatterySOC retrieves the state of charge across all system batteries as a percentage, weighting each
+// battery by its full capacity, so a nearly empty small battery counts less than a large one, e.g. for the
+// internal and removable batteries of dual-battery laptops. Returns ErrNoBattery if there are no batteries.
+func GetCombinedBatterySOC() (float64, error) {
+	levels, err := readCombinedBatteryLevels()
+	if err != nil {
+		return 0, err
+	}
+
+	now, full := 0.0, 0.0
+	for _, level := range levels {
+		now += level.now
+		full += level.full
+	}
+	if full == 0 {
+		return 0, fmt.Errorf("full capacity of the batteries is zero, cannot calculate SOC")
+	}
+
+	return min(100*now/full, 100), nil
+}
+
+// GetCombinedBatteryTime estimates the time until all system batteries are empty or full at their combined rate.
+// The status is Charging if any battery charges, Discharging if any discharges, and Full only if all are full.
+// Returns ErrNoBattery if there are no batteries.
+func GetCombinedBatteryTime() (BatteryTime, error) {
+	batteries, err := ListBatteries()
+	if err != nil {
+		return BatteryTime{}, err
+	}
+	if len(batteries) == 0 {
+		return BatteryTime{}, ErrNoBattery
+	}
+
+	statuses := make(map[BatteryStatus]int)
+	for _, battery := range batteries {
+		status, err := GetBatteryStatus(battery)
+		if err != nil {
+			return BatteryTime{}, err
+		}
+		statuses[status]++
+	}
+
+	estimate := BatteryTime{Status: BatteryNotCharging}
+	switch {
+	case statuses[BatteryCharging] > 0:
+		estimate.Status = BatteryCharging
+	case statuses[BatteryDischarging] > 0:
+		estimate.Status = BatteryDischarging
+	case statuses[BatteryFull] == len(batteries):
+		estimate.Status = BatteryFull
+	}
+
+	levels, err := readCombinedBatteryLevels()
+	if err != nil {
+		return BatteryTime{}, err
+	}
+	now, full, rate := 0.0, 0.0, 0.0
+	for _, level := range levels {
+		now += level.now
+		full += level.full
+		rate += level.rate
+	}
+	if rate == 0 {
+		return estimate, nil
+	}
+
+	switch estimate.Status {
+	case BatteryDischarging:
+		estimate.TimeToEmpty = time.Duration(now / rate * float64(time.Hour))
+	case BatteryCharging:
+		estimate.TimeToFull = time.Duration(max(full-now, 0) / rate * float64(time.Hour))
+	}
+
+	return estimate, nil
+}
+
+// readCombinedBatteryLevels reads the levels of all system batteries as energy, converting batteries that only
+// report charge with their design voltage so batteries of both kinds can be summed.
+func readCombinedBatteryLevels() ([]batteryLevel, error) {
+	batteries, err := ListBatteries()
+	if err != nil {
+		return nil, err
+	}
+	if len(batteries) == 0 {
+		return nil, ErrNoBattery
+	}
+
+	levels := make([]batteryLevel, 0, len(batteries))
+	for _, battery := range batteries {
+		level, err := readBatteryLevel(battery)
+		if err != nil {
+			return nil, err
+		}
+
+		if level.charge {
+			dir := filepath.Join(powerSupplyDir, battery)
+			voltage, err := intFromFile(filepath.Join(dir, "voltage_min_design"))
+			if err != nil {
+				voltage, err = intFromFile(filepath.Join(dir, "voltage_now"))
+			}
+			if err != nil {
+				return nil, fmt.Errorf("failed to get voltage to convert charge of battery %s: %w", battery, err)
+			}
+			// µAh times V is µWh
+			volts := float64(voltage) / 1e6
+			level = batteryLevel{now: level.now * volts, full: level.full * volts, rate: level.rate * volts}
+		}
+
+		levels = append(levels, level)
+	}
+
+	return levels, nil
+}
@@ -0,0 +1,130 @@
+package resourceutil
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"log/slog"
+	"os"
+	"strconv"
+	"strings"
+	"syscall"
+	"time"
+)
+
+// ErrPressureUnsupported is returned when the kernel does not expose pressure stall information (PSI).
+// PSI requires Linux 4.20 or later built with CONFIG_PSI, and may be disabled with psi=0.
+var ErrPressureUnsupported = errors.New("pressure stall information not available")
+
+// PressureStats represents the share of time tasks were stalled on a resource.
+// Fields:
+//   - Avg10 (float64): The percentage of time stalled over the last 10 seconds.
+//   - Avg60 (float64): The percentage of time stalled over the last 60 seconds.
+//   - Avg300 (float64): The percentage of time stalled over the last 300 seconds.
+//   - Total (time.Duration): The total stall time since boot.
+type PressureStats struct {
+	Avg10  float64
+	Avg60  float64
+	Avg300 float64
+	Total  time.Duration
+}
+
+// Pressure represents the pressure stall information of a resource.
+// Fields:
+//   - Some (PressureStats): The time at least one task was stalled on the resource.
+//   - Full (PressureStats): The time all non-idle tasks were stalled at once. For CPU this is only
+//     reported on Linux 5.13 and later and is zero otherwise.
+type Pressure struct {
+	Some PressureStats
+	Full PressureStats
+}
+
+// GetCPUPressure retrieves the CPU pressure stall information from /proc/pressure/cpu.
+// Returns ErrPressureUnsupported if the kernel does not expose PSI.
+func GetCPUPressure() (Pressure, error) {
+	return readPressure("cpu")
+}
+
+// GetMemoryPressure retrieves the memory pressure stall information from /proc/pressure/memory.
+// Some is the time at least one task was stalled on reclaim or refaults and Full the time all tasks were,
+// which rises well before UsedPercent nears 100 and is an early warning of thrashing on the way to an OOM kill.
+// Returns ErrPressureUnsupported if the kernel does not expose PSI.
+func GetMemoryPressure() (Pressure, error) {
+	return readPressure("memory")
+}
+
+// readPressure reads and parses /proc/pressure/<resource>.
+func readPressure(resource string) (Pressure, error) {
+	path := "/proc/pressure/" + resource
+	file, err := os.Open(path)
+	if err != nil {
+		// The files exist but fail with EOPNOTSUPP when PSI is disabled with psi=0
+		if errors.Is(err, os.ErrNotExist) || errors.Is(err, syscall.EOPNOTSUPP) {
+			return Pressure{}, ErrPressureUnsupported
+		}
+		slog.Error("Failed to read pressure info", slog.String("path", path), slog.Any("error", err))
+		return Pressure{}, err
+	}
+	defer file.Close()
+
+	var pressure Pressure
+	scanner := bufio.NewScanner(file)
+	for scanner.Scan() {
+		// Format: some|full avg10=0.00 avg60=0.00 avg300=0.00 total=0
+		fields := strings.Fields(scanner.Text())
+		if len(fields) != 5 {
+			return Pressure{}, fmt.Errorf("unexpected number of fields in %s, line: %s", path, scanner.Text())
+		}
+
+		var stats *PressureStats
+		switch fields[0] {
+		case "some":
+			stats = &pressure.Some
+		case "full":
+			stats = &pressure.Full
+		default:
+			return Pressure{}, fmt.Errorf("unexpected pressure kind in %s: %s", path, fields[0])
+		}
+
+		for _, field := range fields[1:] {
+			key, value, ok := strings.Cut(field, "=")
+			if !ok {
+				return Pressure{}, fmt.Errorf("unexpected field in %s: %s", path, field)
+			}
+
+			if key == "total" {
+				totalMicroseconds, err := strconv.ParseUint(value, 10, 64)
+				if err != nil {
+					return Pressure{}, fmt.Errorf("failed to parse %s in %s: %w", key, path, err)
+				}
+				stats.Total = time.Duration(totalMicroseconds) * time.Microsecond
+				continue
+			}
+
+			avg, err := strconv.ParseFloat(value, 64)
+			if err != nil {
+				return Pressure{}, fmt.Errorf("failed to parse %s in %s: %w", key, path, err)
+			}
+			switch key {
+			case "avg10":
+				stats.Avg10 = avg
+			case "avg60":
+				stats.Avg60 = avg
+			case "avg300":
+				stats.Avg300 = avg
+			}
+		}
+	}
+
+	if err := scanner.Err(); err != nil {
+		if errors.Is(err, syscall.EOPNOTSUPP) {
+			return Pressure{}, ErrPressureUnsupported
+		}
+		slog.Error("Failed to scan pressure info", slog.String("path", path), slog.Any("error", err))
+		return Pressure{}, err
+	}
+
+	slog.Debug("Got pressure info", slog.String("resource", resource), slog.Any("pressure", pressure))
+
+	return pressure, nil
+}
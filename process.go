@@ -0,0 +1,571 @@
+package resourceutil
+
+import (
+	"errors"
+	"fmt"
+	"io/fs"
+	"log/slog"
+	"os"
+	"slices"
+	"strconv"
+	"strings"
+	"syscall"
+	"time"
+)
+
+// ErrProcessNotFound is returned when the requested process does not exist (or has exited).
+var ErrProcessNotFound = errors.New("process not found")
+
+// schedPolicyNames maps the kernel's scheduling policy numbers to their names.
+var schedPolicyNames = map[int]string{
+	0: "SCHED_OTHER",
+	1: "SCHED_FIFO",
+	2: "SCHED_RR",
+	3: "SCHED_BATCH",
+	5: "SCHED_IDLE",
+	6: "SCHED_DEADLINE",
+}
+
+// schedResetOnFork is OR'ed into the policy returned by sched_getscheduler when the flag is set.
+const schedResetOnFork = 0x40000000
+
+// readPIDStat reads /proc/<pid>/stat and returns the command name and the remaining fields.
+// The returned fields start at field 3 (state) of proc(5), so field N is at index N-3.
+func readPIDStat(pid int) (string, []string, error) {
+	path := fmt.Sprintf("/proc/%d/stat", pid)
+	data, err := os.ReadFile(path)
+	if err != nil {
+		if errors.Is(err, fs.ErrNotExist) {
+			return "", nil, ErrProcessNotFound
+		}
+		return "", nil, fmt.Errorf("failed to read %s: %w", path, err)
+	}
+
+	// The command name is wrapped in parentheses and may itself contain spaces or parentheses,
+	// so split on the last closing parenthesis.
+	line := string(data)
+	start := strings.IndexByte(line, '(')
+	end := strings.LastIndexByte(line, ')')
+	if start < 0 || end < start {
+		return "", nil, fmt.Errorf("unexpected format in %s: %s", path, line)
+	}
+
+	return line[start+1 : end], strings.Fields(line[end+1:]), nil
+}
+
+// GetProcessScheduling retrieves the nice value, scheduling policy and real-time priority of a process.
+//
+// The nice value (field 19) and real-time priority (field 40) are read from /proc/<pid>/stat.
+// The policy is queried with sched_getscheduler(2) and falls back to field 41 of /proc/<pid>/stat
+// if the syscall fails, e.g. due to missing permissions. Returns ErrProcessNotFound for dead PIDs.
+func GetProcessScheduling(pid int) (nice int, policy string, rtPriority int, err error) {
+	_, fields, err := readPIDStat(pid)
+	if err != nil {
+		return 0, "", 0, err
+	}
+
+	if len(fields) < 39 {
+		return 0, "", 0, fmt.Errorf("unexpected number of fields in /proc/%d/stat: %d", pid, len(fields))
+	}
+
+	nice, err = strconv.Atoi(fields[19-3])
+	if err != nil {
+		return 0, "", 0, fmt.Errorf("failed to parse nice value for pid %d: %w", pid, err)
+	}
+
+	rtPriority, err = strconv.Atoi(fields[40-3])
+	if err != nil {
+		return 0, "", 0, fmt.Errorf("failed to parse rt_priority for pid %d: %w", pid, err)
+	}
+
+	policyNum, _, errno := syscall.Syscall(syscall.SYS_SCHED_GETSCHEDULER, uintptr(pid), 0, 0)
+	if errno == syscall.ESRCH {
+		return 0, "", 0, ErrProcessNotFound
+	}
+	if errno != 0 {
+		policyNum, err := strconv.Atoi(fields[41-3])
+		if err != nil {
+			return 0, "", 0, fmt.Errorf("failed to parse policy for pid %d: %w", pid, err)
+		}
+		return nice, schedPolicyName(policyNum), rtPriority, nil
+	}
+
+	return nice, schedPolicyName(int(policyNum) &^ schedResetOnFork), rtPriority, nil
+}
+
+// schedPolicyName returns the name of a scheduling policy number, or "UNKNOWN(<n>)" for unknown policies.
+func schedPolicyName(policy int) string {
+	if name, ok := schedPolicyNames[policy]; ok {
+		return name
+	}
+	return fmt.Sprintf("UNKNOWN(%d)", policy)
+}
+
+// pidUsageOptions holds the settings used by GetPIDUsage.
+type pidUsageOptions struct {
+	useLoadAvg bool
+}
+
+// PIDUsageOption configures GetPIDUsage.
+type PIDUsageOption func(*pidUsageOptions)
+
+// WithLoadAvgEstimate makes GetPIDUsage take the current count from the total scheduling entities
+// field of /proc/loadavg instead of scanning /proc. This is much cheaper on busy systems and counts
+// threads as well as processes, which also consume PIDs.
+func WithLoadAvgEstimate() PIDUsageOption {
+	return func(o *pidUsageOptions) {
+		o.useLoadAvg = true
+	}
+}
+
+// GetPIDUsage retrieves the number of PIDs in use, the maximum PID from /proc/sys/kernel/pid_max and the
+// percentage of the PID space in use.
+//
+// By default the current count is the number of /proc/[0-9]* entries, i.e. processes. Threads also
+// consume PIDs, so use WithLoadAvgEstimate for a cheaper count that includes them.
+func GetPIDUsage(opts ...PIDUsageOption) (current int, maxPIDs int, usedPercent float64, err error) {
+	var options pidUsageOptions
+	for _, opt := range opts {
+		opt(&options)
+	}
+
+	maxPIDs, err = intFromFile("/proc/sys/kernel/pid_max")
+	if err != nil {
+		return 0, 0, 0, fmt.Errorf("failed to get pid_max: %w", err)
+	}
+	if maxPIDs == 0 {
+		return 0, 0, 0, fmt.Errorf("divide by zero: pid_max is zero")
+	}
+
+	if options.useLoadAvg {
+		avg, err := readLoadAvg()
+		if err != nil {
+			return 0, 0, 0, err
+		}
+		current = avg.Total
+	} else {
+		pids, err := listPIDs()
+		if err != nil {
+			return 0, 0, 0, err
+		}
+		current = len(pids)
+	}
+
+	usedPercent = 100 * float64(current) / float64(maxPIDs)
+	slog.Debug("Got PID usage", slog.Int("current", current), slog.Int("max", maxPIDs), slog.Float64("used_percent", usedPercent))
+
+	return current, maxPIDs, usedPercent, nil
+}
+
+// listPIDs returns the IDs of all processes listed in /proc.
+func listPIDs() ([]int, error) {
+	entries, err := os.ReadDir("/proc")
+	if err != nil {
+		slog.Error("Failed to list processes", slog.String("path", "/proc"), slog.Any("error", err))
+		return nil, err
+	}
+
+	var pids []int
+	for _, entry := range entries {
+		pid, err := strconv.Atoi(entry.Name())
+		if err != nil {
+			continue
+		}
+		pids = append(pids, pid)
+	}
+
+	return pids, nil
+}
+
+// userHZ is the number of clock ticks per second used for times in /proc, which Linux fixes at 100
+// (USER_HZ) on all mainstream architectures.
+const userHZ = 100
+
+// readProcessCPUTicks returns the CPU time a process has spent in user and kernel mode in clock ticks, along with
+// its start time in clock ticks since boot, which tells a reused PID apart from the original process.
+func readProcessCPUTicks(pid int) (ticks, startTime uint64, err error) {
+	_, fields, err := readPIDStat(pid)
+	if err != nil {
+		return 0, 0, err
+	}
+
+	ticks, err = parseProcessCPUTicks(pid, fields)
+	if err != nil {
+		return 0, 0, err
+	}
+	if len(fields) < 22-3+1 {
+		return 0, 0, fmt.Errorf("unexpected number of fields in /proc/%d/stat: %d", pid, len(fields))
+	}
+	startTime, err = strconv.ParseUint(fields[22-3], 10, 64)
+	if err != nil {
+		return 0, 0, fmt.Errorf("failed to parse starttime for pid %d: %w", pid, err)
+	}
+
+	return ticks, startTime, nil
+}
+
+// parseProcessCPUTicks returns the sum of utime and stime from the fields returned by readPIDStat.
+func parseProcessCPUTicks(pid int, fields []string) (uint64, error) {
+	if len(fields) < 15-3+1 {
+		return 0, fmt.Errorf("unexpected number of fields in /proc/%d/stat: %d", pid, len(fields))
+	}
+
+	utime, err := strconv.ParseUint(fields[14-3], 10, 64)
+	if err != nil {
+		return 0, fmt.Errorf("failed to parse utime for pid %d: %w", pid, err)
+	}
+	stime, err := strconv.ParseUint(fields[15-3], 10, 64)
+	if err != nil {
+		return 0, fmt.Errorf("failed to parse stime for pid %d: %w", pid, err)
+	}
+
+	return utime + stime, nil
+}
+
+// GetProcessCPUUsage measures the CPU usage of a process over the given interval as a percentage of one core,
+// so a process saturating two cores reports 200. This call blocks for the duration of the interval.
+// Returns ErrProcessNotFound if the process does not exist or exits during the interval.
+func GetProcessCPUUsage(pid int, interval time.Duration) (float64, error) {
+	if interval <= 0 {
+		return 0, fmt.Errorf("interval must be positive, got %s", interval)
+	}
+
+	ticks1, startTime1, err := readProcessCPUTicks(pid)
+	if err != nil {
+		return 0, err
+	}
+	start := time.Now()
+
+	time.Sleep(interval)
+
+	ticks2, startTime2, err := readProcessCPUTicks(pid)
+	if err != nil {
+		return 0, err
+	}
+	elapsed := time.Since(start)
+
+	// The process exited and its PID was reused by a new process during the interval
+	if startTime2 != startTime1 || ticks2 < ticks1 {
+		return 0, ErrProcessNotFound
+	}
+
+	usage := 100 * float64(ticks2-ticks1) / userHZ / elapsed.Seconds()
+	slog.Debug("Calculated process CPU usage", slog.Int("pid", pid), slog.Float64("cpu_usage_percent", usage))
+
+	return usage, nil
+}
+
+// ProcessCPU represents the CPU usage of a process.
+// Fields:
+//   - PID (int): The process ID.
+//   - Command (string): The command name of the process.
+//   - CPUPercent (float64): The CPU usage over the measured interval as a percentage of one core.
+type ProcessCPU struct {
+	PID        int
+	Command    string
+	CPUPercent float64
+}
+
+// TopCPUProcesses measures the CPU usage of every process over the given interval and returns the n heaviest
+// consumers, ordered by usage. This is a programmatic snapshot similar to top and blocks for the interval.
+// Processes that start or exit during the interval are not included.
+func TopCPUProcesses(n int, interval time.Duration) ([]ProcessCPU, error) {
+	if n <= 0 {
+		return nil, fmt.Errorf("number of processes must be positive, got %d", n)
+	}
+	if interval <= 0 {
+		return nil, fmt.Errorf("interval must be positive, got %s", interval)
+	}
+
+	type processTicks struct {
+		command string
+		ticks   uint64
+	}
+
+	readAll := func() (map[int]processTicks, error) {
+		pids, err := listPIDs()
+		if err != nil {
+			return nil, err
+		}
+
+		processes := make(map[int]processTicks, len(pids))
+		for _, pid := range pids {
+			command, fields, err := readPIDStat(pid)
+			if err != nil {
+				// The process exited while scanning
+				continue
+			}
+			ticks, err := parseProcessCPUTicks(pid, fields)
+			if err != nil {
+				continue
+			}
+			processes[pid] = processTicks{command: command, ticks: ticks}
+		}
+
+		return processes, nil
+	}
+
+	first, err := readAll()
+	if err != nil {
+		return nil, err
+	}
+	start := time.Now()
+
+	time.Sleep(interval)
+
+	second, err := readAll()
+	if err != nil {
+		return nil, err
+	}
+	elapsed := time.Since(start)
+
+	processes := make([]ProcessCPU, 0, len(second))
+	for pid, after := range second {
+		before, ok := first[pid]
+		// A PID that was reused by a new process has fewer ticks than the old one
+		if !ok || after.ticks < before.ticks {
+			continue
+		}
+		processes = append(processes, ProcessCPU{
+			PID:        pid,
+			Command:    after.command,
+			CPUPercent: 100 * float64(after.ticks-before.ticks) / userHZ / elapsed.Seconds(),
+		})
+	}
+
+	slices.SortFunc(processes, func(a, b ProcessCPU) int {
+		switch {
+		case a.CPUPercent > b.CPUPercent:
+			return -1
+		case a.CPUPercent < b.CPUPercent:
+			return 1
+		default:
+			return a.PID - b.PID
+		}
+	})
+
+	if len(processes) > n {
+		processes = processes[:n]
+	}
+
+	slog.Debug("Got top CPU processes", slog.Any("processes", processes))
+
+	return processes, nil
+}
+
+// readPIDKBValues reads a "Key: value kB" file of a process, such as status or smaps_rollup, and returns
+// the numeric values keyed by name, converted to bytes when they have a kB unit. Non-numeric lines are skipped.
+// Returns ErrProcessNotFound for dead PIDs.
+func readPIDKBValues(pid int, name string) (map[string]uint64, error) {
+	path := fmt.Sprintf("/proc/%d/%s", pid, name)
+	data, err := os.ReadFile(path)
+	if err != nil {
+		if errors.Is(err, fs.ErrNotExist) {
+			return nil, ErrProcessNotFound
+		}
+		return nil, fmt.Errorf("failed to read %s: %w", path, err)
+	}
+
+	values := make(map[string]uint64)
+	for _, line := range strings.Split(string(data), "\n") {
+		key, rest, ok := strings.Cut(line, ":")
+		if !ok {
+			continue
+		}
+		fields := strings.Fields(rest)
+		if len(fields) == 0 {
+			continue
+		}
+		value, err := strconv.ParseUint(fields[0], 10, 64)
+		if err != nil {
+			continue
+		}
+		if len(fields) > 1 && fields[1] == "kB" {
+			value *= 1024
+		}
+		values[key] = value
+	}
+
+	return values, nil
+}
+
+// ProcessMemUsage represents the memory usage of a process.
+// Fields:
+//   - RSSGB (float64): The resident set size in gigabytes.
+//   - VMSizeGB (float64): The virtual memory size in gigabytes.
+//   - SwapGB (float64): The amount of the process swapped out in gigabytes.
+//   - AnonGB (float64): The resident anonymous memory (heap, stacks) in gigabytes.
+//   - FileGB (float64): The resident file mappings (binaries, libraries, mapped files) in gigabytes.
+//   - ShmemGB (float64): The resident shared memory in gigabytes.
+//   - PSSGB (float64): The proportional set size in gigabytes, where shared pages are divided among the processes mapping them. Only set WithPSS.
+//   - SharedGB (float64): The resident memory shared with other processes in gigabytes. Only set WithPSS.
+//   - PrivateGB (float64): The resident memory private to the process in gigabytes. Only set WithPSS.
+type ProcessMemUsage struct {
+	RSSGB     float64
+	VMSizeGB  float64
+	SwapGB    float64
+	AnonGB    float64
+	FileGB    float64
+	ShmemGB   float64
+	PSSGB     float64
+	SharedGB  float64
+	PrivateGB float64
+}
+
+// processMemOptions holds the settings used by GetProcessMemUsage.
+type processMemOptions struct {
+	pss bool
+}
+
+// ProcessMemOption configures GetProcessMemUsage.
+type ProcessMemOption func(*processMemOptions)
+
+// WithPSS makes GetProcessMemUsage also read /proc/<pid>/smaps_rollup for the proportional set size and the
+// exact shared/private split. This is more expensive as the kernel walks all mappings of the process, and
+// requires the same permissions as ptrace for processes of other users.
+func WithPSS() ProcessMemOption {
+	return func(o *processMemOptions) {
+		o.pss = true
+	}
+}
+
+// GetProcessMemUsage retrieves the memory usage of a process from /proc/<pid>/status.
+// Returns ErrProcessNotFound for dead PIDs. Kernel threads report zero usage.
+func GetProcessMemUsage(pid int, opts ...ProcessMemOption) (ProcessMemUsage, error) {
+	var options processMemOptions
+	for _, opt := range opts {
+		opt(&options)
+	}
+
+	const bytesPerGB = 1024 * 1024 * 1024
+
+	status, err := readPIDKBValues(pid, "status")
+	if err != nil {
+		return ProcessMemUsage{}, err
+	}
+
+	usage := ProcessMemUsage{
+		RSSGB:    float64(status["VmRSS"]) / bytesPerGB,
+		VMSizeGB: float64(status["VmSize"]) / bytesPerGB,
+		SwapGB:   float64(status["VmSwap"]) / bytesPerGB,
+		AnonGB:   float64(status["RssAnon"]) / bytesPerGB,
+		FileGB:   float64(status["RssFile"]) / bytesPerGB,
+		ShmemGB:  float64(status["RssShmem"]) / bytesPerGB,
+	}
+
+	if options.pss {
+		rollup, err := readPIDKBValues(pid, "smaps_rollup")
+		if err != nil {
+			return ProcessMemUsage{}, fmt.Errorf("failed to get PSS for pid %d: %w", pid, err)
+		}
+		usage.PSSGB = float64(rollup["Pss"]) / bytesPerGB
+		usage.SharedGB = float64(rollup["Shared_Clean"]+rollup["Shared_Dirty"]) / bytesPerGB
+		usage.PrivateGB = float64(rollup["Private_Clean"]+rollup["Private_Dirty"]) / bytesPerGB
+	}
+
+	slog.Debug("Got process memory usage", slog.Int("pid", pid), slog.Any("process_mem_usage", usage))
+
+	return usage, nil
+}
+
+// SelfMemUsage represents the memory footprint of the calling process as accounted by the kernel.
+// Fields:
+//   - RSSGB (float64): The resident set size in gigabytes.
+//   - PeakRSSGB (float64): The highest resident set size since the process started in gigabytes.
+//   - SwapGB (float64): The amount of the process swapped out in gigabytes.
+//   - RSSBytes, PeakRSSBytes, SwapBytes (uint64): The exact values of the corresponding GB fields in bytes.
+//
+// Unlike runtime.MemStats this includes memory outside the Go heap, such as cgo allocations and mapped
+// files, and excludes heap memory the runtime has returned to the kernel.
+type SelfMemUsage struct {
+	RSSGB     float64
+	PeakRSSGB float64
+	SwapGB    float64
+
+	RSSBytes     uint64
+	PeakRSSBytes uint64
+	SwapBytes    uint64
+}
+
+// GetSelfMemUsage retrieves the memory usage of the calling process from VmRSS, VmHWM and VmSwap in /proc/self/status.
+func GetSelfMemUsage() (SelfMemUsage, error) {
+	status, err := readPIDKBValues(os.Getpid(), "status")
+	if err != nil {
+		return SelfMemUsage{}, err
+	}
+
+	const bytesPerGB = 1024 * 1024 * 1024
+	usage := SelfMemUsage{
+		RSSGB:        float64(status["VmRSS"]) / bytesPerGB,
+		PeakRSSGB:    float64(status["VmHWM"]) / bytesPerGB,
+		SwapGB:       float64(status["VmSwap"]) / bytesPerGB,
+		RSSBytes:     status["VmRSS"],
+		PeakRSSBytes: status["VmHWM"],
+		SwapBytes:    status["VmSwap"],
+	}
+	slog.Debug("Got self memory usage", slog.Any("self_mem_usage", usage))
+
+	return usage, nil
+}
+
+// ProcessMem represents the resident memory of a process.
+// Fields:
+//   - PID (int): The process ID.
+//   - Command (string): The command name of the process.
+//   - RSSGB (float64): The resident set size in gigabytes.
+type ProcessMem struct {
+	PID     int
+	Command string
+	RSSGB   float64
+}
+
+// TopMemProcesses scans /proc and returns the n processes with the largest resident set size, ordered by size.
+// The resident set size is taken from field 24 of /proc/<pid>/stat so each process is only read once.
+func TopMemProcesses(n int) ([]ProcessMem, error) {
+	if n <= 0 {
+		return nil, fmt.Errorf("number of processes must be positive, got %d", n)
+	}
+
+	pids, err := listPIDs()
+	if err != nil {
+		return nil, err
+	}
+
+	pageSize := float64(os.Getpagesize())
+	processes := make([]ProcessMem, 0, len(pids))
+	for _, pid := range pids {
+		command, fields, err := readPIDStat(pid)
+		if err != nil || len(fields) < 24-3+1 {
+			// The process exited while scanning
+			continue
+		}
+		rssPages, err := strconv.ParseUint(fields[24-3], 10, 64)
+		if err != nil {
+			continue
+		}
+		processes = append(processes, ProcessMem{
+			PID:     pid,
+			Command: command,
+			RSSGB:   float64(rssPages) * pageSize / (1024 * 1024 * 1024),
+		})
+	}
+
+	slices.SortFunc(processes, func(a, b ProcessMem) int {
+		switch {
+		case a.RSSGB > b.RSSGB:
+			return -1
+		case a.RSSGB < b.RSSGB:
+			return 1
+		default:
+			return a.PID - b.PID
+		}
+	})
+
+	if len(processes) > n {
+		processes = processes[:n]
+	}
+
+	slog.Debug("Got top memory processes", slog.Any("processes", processes))
+
+	return processes, nil
+}
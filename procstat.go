@@ -0,0 +1,329 @@
+package resourceutil
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"log/slog"
+	"os"
+	"strconv"
+)
+
+// Indexes of the time fields of a cpu line in /proc/stat.
+//
+// The CPU statistics line in /proc/stat has these fields after the prefix
+// ("cpu" for aggregate statistics or "cpuN" for individual cores):
+//
+// Core CPU statistics fields
+//   - User       - Time spent in user mode
+//   - Nice       - Time spent in user mode with low priority (nice)
+//   - System     - Time spent in system mode
+//   - Idle       - Time spent in the idle task
+//
+// Added in Linux 2.5.41:
+//   - IOWait     - Time spent waiting for I/O to complete
+//   - IRQ        - Time spent servicing hardware interrupts
+//   - SoftIRQ    - Time spent servicing software interrupts
+//
+// Added in Linux 2.6.11:
+//   - Steal      - Time spent in other operating systems when running in a virtualized environment
+//
+// Added in Linux 2.6.24:
+//   - Guest      - Time spent running a virtual CPU for guest operating systems
+//   - GuestNice  - Time spent running a low-priority virtual CPU for guest operating systems
+const (
+	cpuUser = iota
+	cpuNice
+	cpuSystem
+	cpuIdle
+	cpuIOWait
+	cpuIRQ
+	cpuSoftIRQ
+	cpuSteal
+	cpuGuest
+	cpuGuestNice
+	numCPUFields
+)
+
+// cpuTimes holds the cumulative time counters of a cpu line in /proc/stat in USER_HZ.
+// Fields not reported by older kernels are zero.
+type cpuTimes [numCPUFields]float64
+
+// total returns the sum of all time counters except Guest and GuestNice, which the kernel already counts
+// in User and Nice.
+func (t cpuTimes) total() float64 {
+	total := 0.0
+	for _, value := range t[:cpuGuest] {
+		total += value
+	}
+	return total
+}
+
+// idle returns the time spent idle, including time spent waiting for I/O.
+func (t cpuTimes) idle() float64 {
+	return t[cpuIdle] + t[cpuIOWait]
+}
+
+// IdleDefinition selects which CPU time counts as idle when calculating the CPU load.
+type IdleDefinition int
+
+const (
+	// IdleOnly counts only the idle task as idle, so time waiting for I/O counts as busy.
+	IdleOnly IdleDefinition = iota
+	// IdleWithIOWait counts the idle task and time waiting for I/O as idle, like top.
+	IdleWithIOWait
+	// IdleWithIOWaitAndSteal additionally counts time stolen by the hypervisor as idle.
+	IdleWithIOWaitAndSteal
+)
+
+// idleAs returns the time spent idle according to the given definition.
+func (t cpuTimes) idleAs(idle IdleDefinition) float64 {
+	switch idle {
+	case IdleOnly:
+		return t[cpuIdle]
+	case IdleWithIOWaitAndSteal:
+		return t[cpuIdle] + t[cpuIOWait] + t[cpuSteal]
+	default:
+		return t.idle()
+	}
+}
+
+// procStat holds the parsed contents of /proc/stat.
+// Fields:
+//   - cpu (cpuTimes): The aggregate time counters of all CPUs.
+//   - cores (map[int]cpuTimes): The time counters of each online CPU keyed by CPU number.
+//   - contextSwitches (uint64): The number of context switches since boot (ctxt).
+//   - forks (uint64): The number of processes and threads created since boot (processes).
+//   - procsRunning (int): The number of runnable threads (procs_running).
+//   - procsBlocked (int): The number of threads blocked waiting for I/O (procs_blocked).
+type procStat struct {
+	cpu             cpuTimes
+	cores           map[int]cpuTimes
+	contextSwitches uint64
+	forks           uint64
+	procsRunning    int
+	procsBlocked    int
+}
+
+// readProcStat reads and parses /proc/stat.
+func readProcStat() (procStat, error) {
+	reader, err := newProcStatReader()
+	if err != nil {
+		return procStat{}, err
+	}
+	defer reader.close()
+
+	var stat procStat
+	if err := reader.read(&stat); err != nil {
+		return procStat{}, err
+	}
+
+	return stat, nil
+}
+
+// procStatReader reads /proc/stat repeatedly through one open file, reusing its buffers between reads.
+// The measurement loop reads /proc/stat twice per sample, so this avoids reopening the file and
+// allocating on every read. A procStatReader is not safe for concurrent use.
+type procStatReader struct {
+	file   *os.File
+	buf    []byte
+	fields [][]byte
+}
+
+// newProcStatReader opens /proc/stat for repeated reads.
+func newProcStatReader() (*procStatReader, error) {
+	file, err := os.Open("/proc/stat")
+	if err != nil {
+		slog.Error("Failed to read process info", slog.String("path", "/proc/stat"), slog.Any("error", err))
+		return nil, err
+	}
+
+	return &procStatReader{file: file, buf: make([]byte, 4096)}, nil
+}
+
+// close closes the underlying file.
+func (r *procStatReader) close() error {
+	return r.file.Close()
+}
+
+// read rereads /proc/stat from the start and parses it into stat, reusing its cores map.
+func (r *procStatReader) read(stat *procStat) error {
+	if _, err := r.file.Seek(0, io.SeekStart); err != nil {
+		return fmt.Errorf("failed to rewind /proc/stat: %w", err)
+	}
+
+	// The kernel generates the file on read, so read until EOF and grow the buffer if it fills up.
+	n := 0
+	for {
+		if n == len(r.buf) {
+			r.buf = append(r.buf, make([]byte, len(r.buf))...)
+		}
+		read, err := r.file.Read(r.buf[n:])
+		n += read
+		if err == io.EOF || (err == nil && read == 0) {
+			break
+		}
+		if err != nil {
+			slog.Error("Failed to scan /proc/stat", slog.Any("error", err))
+			return err
+		}
+	}
+
+	return r.parse(r.buf[:n], stat)
+}
+
+// parse parses the contents of /proc/stat into stat.
+func (r *procStatReader) parse(data []byte, stat *procStat) error {
+	if stat.cores == nil {
+		stat.cores = make(map[int]cpuTimes)
+	} else {
+		clear(stat.cores)
+	}
+	foundCPU := false
+
+	for len(data) > 0 {
+		line := data
+		if i := bytes.IndexByte(data, '\n'); i >= 0 {
+			line, data = data[:i], data[i+1:]
+		} else {
+			data = nil
+		}
+
+		r.fields = splitFields(r.fields[:0], line)
+		if len(r.fields) == 0 {
+			continue
+		}
+
+		name, isCPU := bytes.CutPrefix(r.fields[0], []byte("cpu"))
+		if !isCPU {
+			if err := parseActivityLine(stat, r.fields); err != nil {
+				return err
+			}
+			continue
+		}
+
+		times, err := parseCPULine(r.fields)
+		if err != nil {
+			return err
+		}
+
+		if len(name) == 0 {
+			if slog.Default().Enabled(context.Background(), slog.LevelDebug) {
+				slog.Debug("Found CPU line", slog.String("cpu_statistics", string(line)))
+			}
+			stat.cpu = times
+			foundCPU = true
+			continue
+		}
+
+		cpu, err := parseUint(name)
+		if err != nil {
+			return fmt.Errorf("failed to parse CPU number in /proc/stat, cpu line: %s", line)
+		}
+		stat.cores[int(cpu)] = times
+	}
+
+	if !foundCPU {
+		return fmt.Errorf("no aggregate cpu line found in /proc/stat")
+	}
+
+	return nil
+}
+
+// splitFields appends the space separated fields of line to fields and returns the extended slice.
+// The fields alias line.
+func splitFields(fields [][]byte, line []byte) [][]byte {
+	start := -1
+	for i, c := range line {
+		if c == ' ' || c == '\t' {
+			if start >= 0 {
+				fields = append(fields, line[start:i])
+				start = -1
+			}
+			continue
+		}
+		if start < 0 {
+			start = i
+		}
+	}
+	if start >= 0 {
+		fields = append(fields, line[start:])
+	}
+
+	return fields
+}
+
+// parseUint parses a decimal unsigned integer without allocating.
+func parseUint(b []byte) (uint64, error) {
+	if len(b) == 0 {
+		return 0, strconv.ErrSyntax
+	}
+
+	var value uint64
+	for _, c := range b {
+		if c < '0' || c > '9' {
+			return 0, strconv.ErrSyntax
+		}
+		next := value*10 + uint64(c-'0')
+		if next < value {
+			return 0, strconv.ErrRange
+		}
+		value = next
+	}
+
+	return value, nil
+}
+
+// parseCPULine parses the fields of a cpu line in /proc/stat, including the prefix.
+func parseCPULine(fields [][]byte) (cpuTimes, error) {
+	var times cpuTimes
+
+	// Validate the number of fields
+	if len(fields) > numCPUFields+1 || len(fields) < 5 {
+		return times, fmt.Errorf("unexpected number of CPU fields in /proc/stat, cpu line: %s", bytes.Join(fields, []byte(" ")))
+	}
+
+	for i := 1; i < len(fields); i++ {
+		value, err := parseUint(fields[i])
+		if err != nil {
+			return times, fmt.Errorf("failed to parse CPU field %d: %w", i, err)
+		}
+		times[i-1] = float64(value)
+	}
+
+	return times, nil
+}
+
+// parseActivityLine parses the ctxt, processes, procs_running and procs_blocked lines of /proc/stat into stat.
+// Other lines are ignored.
+func parseActivityLine(stat *procStat, fields [][]byte) error {
+	if len(fields) != 2 {
+		return nil
+	}
+
+	// Switching on a converted byte slice does not allocate
+	switch string(fields[0]) {
+	case "ctxt", "processes", "procs_running", "procs_blocked":
+	default:
+		return nil
+	}
+
+	value, err := parseUint(fields[1])
+	if err != nil {
+		return fmt.Errorf("failed to parse %s in /proc/stat: %w", fields[0], err)
+	}
+
+	switch string(fields[0]) {
+	case "ctxt":
+		stat.contextSwitches = value
+	case "processes":
+		stat.forks = value
+	case "procs_running":
+		stat.procsRunning = int(value)
+	case "procs_blocked":
+		stat.procsBlocked = int(value)
+	}
+
+	return nil
+}
@@ -0,0 +1,133 @@
+package resourceutil
+
+import (
+	"errors"
+	"fmt"
+	"log/slog"
+	"runtime"
+	"strings"
+	"syscall"
+	"unsafe"
+)
+
+const hwRandomDir = "/sys/class/misc/hw_random"
+
+var (
+	// ErrHardwareRNGUnavailable is returned when no hardware RNG is registered with the kernel.
+	ErrHardwareRNGUnavailable = errors.New("hardware RNG information unavailable")
+	// ErrCRNGStateUnavailable is returned when the initialization state of the kernel CRNG cannot be determined.
+	ErrCRNGStateUnavailable = errors.New("CRNG initialization state unavailable")
+)
+
+// RNGInfo represents the state of the kernel's random number generation.
+// Fields:
+//   - HardwareRNG (string): The active hardware RNG from rng_current, empty if none.
+//   - AvailableHardwareRNGs ([]string): The hardware RNGs registered with the kernel.
+//   - CRNGInitialized (bool): Whether the kernel CRNG has been seeded and no longer blocks.
+//   - EntropyAvailable (int): The entropy in the input pool in bits.
+type RNGInfo struct {
+	HardwareRNG           string
+	AvailableHardwareRNGs []string
+	CRNGInitialized       bool
+	EntropyAvailable      int
+}
+
+// GetEntropyAvailable retrieves the entropy available in the kernel's input pool in bits.
+func GetEntropyAvailable() (int, error) {
+	entropy, err := intFromFile("/proc/sys/kernel/random/entropy_avail")
+	if err != nil {
+		slog.Error("Failed to read available entropy", slog.Any("error", err))
+		return 0, err
+	}
+
+	return entropy, nil
+}
+
+// GetRNGInfo retrieves the active hardware RNG and whether the kernel CRNG is initialized.
+//
+// The information is best-effort: fields that cannot be read are left at their zero value and
+// the returned error wraps ErrHardwareRNGUnavailable and/or ErrCRNGStateUnavailable, which can be
+// checked with errors.Is. The remaining fields are still valid in that case.
+//
+// The CRNG state is determined by a non-blocking getrandom(2) call, which requires Linux 3.17 or later.
+func GetRNGInfo() (RNGInfo, error) {
+	var info RNGInfo
+	var errs []error
+
+	current, err := stringFromFile(hwRandomDir + "/rng_current")
+	if err != nil {
+		errs = append(errs, fmt.Errorf("%w: %w", ErrHardwareRNGUnavailable, err))
+	} else if current != "none" {
+		info.HardwareRNG = current
+	}
+
+	if available, err := stringFromFile(hwRandomDir + "/rng_available"); err == nil {
+		for _, name := range strings.Fields(available) {
+			if name != "none" {
+				info.AvailableHardwareRNGs = append(info.AvailableHardwareRNGs, name)
+			}
+		}
+	}
+
+	initialized, err := isCRNGInitialized()
+	if err != nil {
+		errs = append(errs, fmt.Errorf("%w: %w", ErrCRNGStateUnavailable, err))
+	}
+	info.CRNGInitialized = initialized
+
+	entropy, err := GetEntropyAvailable()
+	if err != nil {
+		errs = append(errs, fmt.Errorf("failed to get available entropy: %w", err))
+	}
+	info.EntropyAvailable = entropy
+
+	slog.Debug("Got RNG info", slog.Any("rng_info", info))
+
+	return info, errors.Join(errs...)
+}
+
+// grndNonblock makes getrandom(2) fail with EAGAIN instead of blocking while the CRNG is not initialized.
+const grndNonblock = 0x1
+
+// getrandomSyscalls holds the getrandom(2) system call number of each architecture, since the syscall
+// package does not define SYS_GETRANDOM for all of them.
+var getrandomSyscalls = map[string]uintptr{
+	"386":      355,
+	"amd64":    318,
+	"arm":      384,
+	"arm64":    278,
+	"loong64":  278,
+	"mips":     4353,
+	"mipsle":   4353,
+	"mips64":   5313,
+	"mips64le": 5313,
+	"ppc64":    359,
+	"ppc64le":  359,
+	"riscv64":  278,
+	"s390x":    349,
+}
+
+// isCRNGInitialized checks whether a non-blocking getrandom(2) call succeeds, which on every kernel since 3.17
+// only fails with EAGAIN until the CRNG is initialized. Unlike reading /dev/random it does not depend on the
+// entropy estimate of the blocking pool of kernels before 5.6. The random byte read is discarded.
+func isCRNGInitialized() (bool, error) {
+	trap, ok := getrandomSyscalls[runtime.GOARCH]
+	if !ok {
+		return false, fmt.Errorf("getrandom system call number unknown for %s", runtime.GOARCH)
+	}
+
+	var buf [1]byte
+	for {
+		_, _, errno := syscall.Syscall(trap, uintptr(unsafe.Pointer(&buf[0])), uintptr(len(buf)), grndNonblock)
+		switch errno {
+		case 0:
+			return true, nil
+		case syscall.EAGAIN:
+			return false, nil
+		case syscall.EINTR:
+			continue
+		default:
+			return false, fmt.Errorf("failed to call getrandom: %w", errno)
+		}
+	}
+}
@@ -0,0 +1,53 @@
+package resourceutil
+
+// ringBuffer is a fixed-capacity buffer that overwrites its oldest element once full, so inserting is O(1)
+// regardless of the capacity. It is not safe for concurrent use, callers guard it with their own mutex.
+// A mutex is used rather than a lock-free design because readers aggregate over many elements at once,
+// which would need a consistent snapshot of the whole buffer, and the lock is only held for O(window) work
+// once per sample interval, so contention is negligible.
+type ringBuffer[T any] struct {
+	items []T
+	// next is the index the next element is written to.
+	next  int
+	count int
+}
+
+// newRingBuffer creates an empty ring buffer holding up to capacity elements.
+func newRingBuffer[T any](capacity int) *ringBuffer[T] {
+	return &ringBuffer[T]{items: make([]T, capacity)}
+}
+
+// push adds an element, overwriting the oldest one if the buffer is full.
+func (b *ringBuffer[T]) push(item T) {
+	b.items[b.next] = item
+	b.next = (b.next + 1) % len(b.items)
+	b.count = min(b.count+1, len(b.items))
+}
+
+// reset removes all elements.
+func (b *ringBuffer[T]) reset() {
+	clear(b.items)
+	b.next = 0
+	b.count = 0
+}
+
+// len returns the number of elements in the buffer.
+func (b *ringBuffer[T]) len() int {
+	return b.count
+}
+
+// newest returns the i-th newest element, newest(0) being the last one pushed. i must be less than len.
+func (b *ringBuffer[T]) newest(i int) T {
+	return b.items[(b.next-1-i+len(b.items))%len(b.items)]
+}
+
+// oldestFirst returns a copy of the n newest elements ordered from oldest to newest.
+// n is capped at len.
+func (b *ringBuffer[T]) oldestFirst(n int) []T {
+	n = min(n, b.count)
+	items := make([]T, n)
+	for i := range n {
+		items[n-1-i] = b.newest(i)
+	}
+	return items
+}
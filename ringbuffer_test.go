@@ -0,0 +1,83 @@
+package resourceutil
+
+import (
+	"slices"
+	"testing"
+)
+
+func TestRingBufferPush(t *testing.T) {
+	b := newRingBuffer[int](3)
+	if b.len() != 0 {
+		t.Fatalf("len() = %d, want 0", b.len())
+	}
+
+	b.push(1)
+	b.push(2)
+	if b.len() != 2 {
+		t.Fatalf("len() = %d, want 2", b.len())
+	}
+	if got := b.newest(0); got != 2 {
+		t.Errorf("newest(0) = %d, want 2", got)
+	}
+	if got := b.newest(1); got != 1 {
+		t.Errorf("newest(1) = %d, want 1", got)
+	}
+}
+
+func TestRingBufferWraparound(t *testing.T) {
+	b := newRingBuffer[int](3)
+	for i := 1; i <= 7; i++ {
+		b.push(i)
+	}
+
+	if b.len() != 3 {
+		t.Fatalf("len() = %d, want 3", b.len())
+	}
+	for i, want := range []int{7, 6, 5} {
+		if got := b.newest(i); got != want {
+			t.Errorf("newest(%d) = %d, want %d", i, got, want)
+		}
+	}
+	if got := b.oldestFirst(3); !slices.Equal(got, []int{5, 6, 7}) {
+		t.Errorf("oldestFirst(3) = %v, want [5 6 7]", got)
+	}
+	if got := b.oldestFirst(2); !slices.Equal(got, []int{6, 7}) {
+		t.Errorf("oldestFirst(2) = %v, want [6 7]", got)
+	}
+	if got := b.oldestFirst(10); !slices.Equal(got, []int{5, 6, 7}) {
+		t.Errorf("oldestFirst(10) = %v, want [5 6 7]", got)
+	}
+}
+
+func TestRingBufferOldestFirstCopies(t *testing.T) {
+	b := newRingBuffer[int](2)
+	b.push(1)
+	b.push(2)
+
+	items := b.oldestFirst(2)
+	items[0] = 100
+	if got := b.newest(1); got != 1 {
+		t.Errorf("newest(1) = %d after modifying the copy, want 1", got)
+	}
+}
+
+func TestRingBufferReset(t *testing.T) {
+	b := newRingBuffer[int](3)
+	for i := range 5 {
+		b.push(i)
+	}
+
+	b.reset()
+	if b.len() != 0 {
+		t.Fatalf("len() = %d after reset, want 0", b.len())
+	}
+	if got := b.oldestFirst(3); len(got) != 0 {
+		t.Errorf("oldestFirst(3) = %v after reset, want empty", got)
+	}
+
+	b.push(10)
+	b.push(11)
+	if got := b.oldestFirst(3); !slices.Equal(got, []int{10, 11}) {
+		t.Errorf("oldestFirst(3) = %v after reset and push, want [10 11]", got)
+	}
+}
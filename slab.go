@@ -0,0 +1,127 @@
+package resourceutil
+
+import (
+	"bufio"
+	"fmt"
+	"log/slog"
+	"os"
+	"slices"
+	"strconv"
+	"strings"
+)
+
+// SlabUsage represents the memory used by the kernel slab allocator.
+// Fields:
+//   - SlabGB (float64): The total slab memory in gigabytes.
+//   - SReclaimableGB (float64): The slab memory that can be reclaimed under pressure, such as dentry and inode caches, in gigabytes.
+//   - SUnreclaimGB (float64): The slab memory that cannot be reclaimed in gigabytes.
+type SlabUsage struct {
+	SlabGB         float64
+	SReclaimableGB float64
+	SUnreclaimGB   float64
+}
+
+// SlabCache represents a kernel slab cache from /proc/slabinfo.
+// Fields:
+//   - Name (string): The name of the cache, e.g. "dentry" or "inode_cache".
+//   - ActiveObjects (uint64): The number of objects in use.
+//   - Objects (uint64): The number of allocated objects, in use or not.
+//   - ObjectSize (uint64): The size of each object in bytes.
+//   - SizeGB (float64): The memory held by the slabs of the cache in gigabytes.
+type SlabCache struct {
+	Name          string
+	ActiveObjects uint64
+	Objects       uint64
+	ObjectSize    uint64
+	SizeGB        float64
+}
+
+// GetSlabUsage retrieves the slab memory from Slab, SReclaimable and SUnreclaim in /proc/meminfo.
+func GetSlabUsage() (SlabUsage, error) {
+	info, err := GetMemInfo()
+	if err != nil {
+		return SlabUsage{}, err
+	}
+
+	const bytesPerGB = 1024 * 1024 * 1024
+	return SlabUsage{
+		SlabGB:         float64(info.Slab) / bytesPerGB,
+		SReclaimableGB: float64(info.SReclaimable) / bytesPerGB,
+		SUnreclaimGB:   float64(info.SUnreclaim) / bytesPerGB,
+	}, nil
+}
+
+// GetTopSlabCaches parses /proc/slabinfo and returns the n slab caches holding the most memory, ordered by size.
+// This finds the cache behind unexplained slab growth, e.g. a dentry cache leak. /proc/slabinfo is only
+// readable by root.
+func GetTopSlabCaches(n int) ([]SlabCache, error) {
+	if n <= 0 {
+		return nil, fmt.Errorf("number of slab caches must be positive, got %d", n)
+	}
+
+	file, err := os.Open("/proc/slabinfo")
+	if err != nil {
+		slog.Error("Failed to read slab info", slog.String("path", "/proc/slabinfo"), slog.Any("error", err))
+		return nil, err
+	}
+	defer file.Close()
+
+	pageSize := uint64(os.Getpagesize())
+	var caches []SlabCache
+	scanner := bufio.NewScanner(file)
+
+	for scanner.Scan() {
+		line := scanner.Text()
+		// Skip the version and the column header lines
+		if strings.HasPrefix(line, "slabinfo") || strings.HasPrefix(line, "#") {
+			continue
+		}
+
+		// Fields: name active_objs num_objs objsize objperslab pagesperslab : tunables ... : slabdata active_slabs num_slabs sharedavail
+		fields := strings.Fields(line)
+		if len(fields) < 16 {
+			return nil, fmt.Errorf("unexpected number of fields in /proc/slabinfo, line: %s", line)
+		}
+
+		var values [5]uint64
+		for i, index := range []int{1, 2, 3, 5, 14} {
+			values[i], err = strconv.ParseUint(fields[index], 10, 64)
+			if err != nil {
+				return nil, fmt.Errorf("failed to parse slab cache %s: %w", fields[0], err)
+			}
+		}
+		activeObjects, objects, objectSize, pagesPerSlab, slabs := values[0], values[1], values[2], values[3], values[4]
+
+		caches = append(caches, SlabCache{
+			Name:          fields[0],
+			ActiveObjects: activeObjects,
+			Objects:       objects,
+			ObjectSize:    objectSize,
+			SizeGB:        float64(slabs*pagesPerSlab*pageSize) / (1024 * 1024 * 1024),
+		})
+	}
+
+	if err := scanner.Err(); err != nil {
+		slog.Error("Failed to scan /proc/slabinfo", slog.Any("error", err))
+		return nil, err
+	}
+
+	slices.SortFunc(caches, func(a, b SlabCache) int {
+		switch {
+		case a.SizeGB > b.SizeGB:
+			return -1
+		case a.SizeGB < b.SizeGB:
+			return 1
+		default:
+			return strings.Compare(a.Name, b.Name)
+		}
+	})
+
+	if len(caches) > n {
+		caches = caches[:n]
+	}
+
+	slog.Debug("Got top slab caches", slog.Any("slab_caches", caches))
+
+	return caches, nil
+}
@@ -0,0 +1,105 @@
+package resourceutil
+
+import (
+	"context"
+	"log/slog"
+	"time"
+)
+
+// SystemSnapshot represents the state of the main system resources at a point in time.
+// Fields:
+//   - Timestamp (time.Time): The time the snapshot was taken.
+//   - CPULoad (float64): The CPU load in percent since the previous snapshot.
+//   - Mem (MemUsage): The memory usage.
+//   - Disk (StorageUsage): The disk usage of the configured path.
+type SystemSnapshot struct {
+	Timestamp time.Time
+	CPULoad   float64
+	Mem       MemUsage
+	Disk      StorageUsage
+}
+
+// snapshotOptions holds the settings used by StreamSnapshots.
+type snapshotOptions struct {
+	diskPath string
+}
+
+// SnapshotOption configures StreamSnapshots.
+type SnapshotOption func(*snapshotOptions)
+
+// WithSnapshotDiskPath sets the file system path whose disk usage is included in snapshots, default "/".
+func WithSnapshotDiskPath(path string) SnapshotOption {
+	return func(o *snapshotOptions) {
+		o.diskPath = path
+	}
+}
+
+// StreamSnapshots sends a SystemSnapshot on the returned channel every interval until ctx is cancelled,
+// after which the channel is closed. The CPU load of each snapshot is measured over the preceding interval,
+// so the background measurement loop is not needed. Snapshots are dropped if the receiver falls behind.
+//
+// Resources that fail to be read are logged and left at their zero value in the snapshot.
+func StreamSnapshots(ctx context.Context, interval time.Duration, opts ...SnapshotOption) <-chan SystemSnapshot {
+	options := snapshotOptions{diskPath: "/"}
+	for _, opt := range opts {
+		opt(&options)
+	}
+
+	snapshots := make(chan SystemSnapshot, 1)
+
+	go func() {
+		defer close(snapshots)
+
+		if interval <= 0 {
+			slog.Error("Unable to stream snapshots with non-positive interval", slog.Duration("interval", interval))
+			return
+		}
+
+		totalTime, idleTime, err := readCPUStats()
+		if err != nil {
+			slog.Warn("Failed to read initial CPU statistics for snapshot", slog.Any("error", err))
+		}
+
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+			}
+
+			snapshot := SystemSnapshot{Timestamp: time.Now()}
+
+			nextTotalTime, nextIdleTime, err := readCPUStats()
+			if err != nil {
+				slog.Warn("Failed to read CPU statistics for snapshot", slog.Any("error", err))
+			} else {
+				// Without a previous reading there is no interval to measure the load over.
+				if totalTime > 0 {
+					if cpuLoad, err := calculateCPULoad(totalTime, idleTime, nextTotalTime, nextIdleTime); err == nil {
+						snapshot.CPULoad = cpuLoad
+					}
+				}
+				totalTime, idleTime = nextTotalTime, nextIdleTime
+			}
+
+			if snapshot.Mem, err = GetMemUsage(); err != nil {
+				slog.Warn("Failed to get memory usage for snapshot", slog.Any("error", err))
+			}
+
+			if snapshot.Disk, err = GetDiskUsage(options.diskPath); err != nil {
+				slog.Warn("Failed to get disk usage for snapshot", slog.Any("error", err))
+			}
+
+			select {
+			case snapshots <- snapshot:
+			default:
+				slog.Debug("Dropped snapshot as receiver is not keeping up", slog.Time("timestamp", snapshot.Timestamp))
+			}
+		}
+	}()
+
+	return snapshots
+}
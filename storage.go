@@ -1,21 +1,40 @@
 package resourceutil
 
 import (
+	"bufio"
+	"errors"
+	"fmt"
 	"log/slog"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
 	"syscall"
 )
 
+var (
+	// ErrDeviceNotResolved is returned when the block device backing a path cannot be determined.
+	ErrDeviceNotResolved = errors.New("block device could not be resolved")
+	// ErrRotationalUnsupported is returned when a block device does not expose whether it is rotational.
+	ErrRotationalUnsupported = errors.New("block device does not expose rotational attribute")
+)
+
 // StorageUsage represents disk storage metrics.
 // Fields:
 //   - TotalGB (float64): The total storage capacity in gigabytes.
 //   - FreeGB (float64): The available storage in gigabytes for non-root users.
 //   - UsedGB (float64): The amount of used storage in gigabytes.
 //   - UsedPercent (float64): The percentage of storage in use.
+//   - TotalBytes, FreeBytes, UsedBytes (uint64): The exact values of the corresponding GB fields in bytes.
 type StorageUsage struct {
 	TotalGB     float64
 	FreeGB      float64
 	UsedGB      float64
 	UsedPercent float64
+
+	TotalBytes uint64
+	FreeBytes  uint64
+	UsedBytes  uint64
 }
 
 // GetDiskUsage retrieves disk usage statistics for a given file system path.
@@ -27,10 +46,17 @@ func GetDiskUsage(path string) (StorageUsage, error) {
 		return StorageUsage{}, err
 	}
 
+	// The block counts are in units of the fundamental block size (Frsize), which can differ
+	// from the preferred I/O size (Bsize). Fall back to Bsize for filesystems that leave Frsize unset.
+	blockSize := uint64(stat.Frsize)
+	if blockSize == 0 {
+		blockSize = uint64(stat.Bsize)
+	}
+
 	// Calculate total, free, and used bytes.
-	total := stat.Blocks * uint64(stat.Bsize) // Total bytes
-	free := stat.Bavail * uint64(stat.Bsize)  // Available bytes to non-root users
-	used := total - free                      // Used bytes
+	total := stat.Blocks * blockSize // Total bytes
+	free := stat.Bavail * blockSize  // Available bytes to non-root users
+	used := total - free             // Used bytes
 
 	const bytesPerGB = 1024 * 1024 * 1024
 	totalGB := float64(total) / bytesPerGB
@@ -44,9 +70,257 @@ func GetDiskUsage(path string) (StorageUsage, error) {
 		FreeGB:      freeGB,
 		UsedGB:      usedGB,
 		UsedPercent: usedPercent,
+		TotalBytes:  total,
+		FreeBytes:   free,
+		UsedBytes:   used,
 	}
 
 	slog.Debug("Got disk usage", slog.Any("disk_usage", storageUsage))
 
 	return storageUsage, nil
 }
+
+// mountEntry represents a single mount from /proc/self/mountinfo.
+type mountEntry struct {
+	majorMinor string
+	mountPoint string
+	fsType     string
+	source     string
+}
+
+// readMountInfo reads and parses the mounts visible to the calling process from /proc/self/mountinfo.
+func readMountInfo() ([]mountEntry, error) {
+	file, err := os.Open("/proc/self/mountinfo")
+	if err != nil {
+		slog.Error("Failed to read mount info", slog.String("path", "/proc/self/mountinfo"), slog.Any("error", err))
+		return nil, err
+	}
+	defer file.Close()
+
+	var mounts []mountEntry
+	scanner := bufio.NewScanner(file)
+	for scanner.Scan() {
+		// Format: id parent major:minor root mount_point options [optional fields...] - fs_type source super_options
+		fields := strings.Fields(scanner.Text())
+		separator := -1
+		for i, field := range fields {
+			if field == "-" {
+				separator = i
+				break
+			}
+		}
+		if len(fields) < 5 || separator < 0 || separator+2 >= len(fields) {
+			return nil, fmt.Errorf("unexpected format in /proc/self/mountinfo, line: %s", scanner.Text())
+		}
+
+		mounts = append(mounts, mountEntry{
+			majorMinor: fields[2],
+			mountPoint: unescapeMountField(fields[4]),
+			fsType:     fields[separator+1],
+			source:     unescapeMountField(fields[separator+2]),
+		})
+	}
+
+	if err := scanner.Err(); err != nil {
+		slog.Error("Failed to scan /proc/self/mountinfo", slog.Any("error", err))
+		return nil, err
+	}
+
+	return mounts, nil
+}
+
+// unescapeMountField decodes the octal escapes (e.g. \040 for a space) used in mountinfo fields.
+func unescapeMountField(field string) string {
+	if !strings.Contains(field, `\`) {
+		return field
+	}
+
+	var b strings.Builder
+	for i := 0; i < len(field); i++ {
+		if field[i] == '\\' && i+3 < len(field) {
+			if value, err := strconv.ParseUint(field[i+1:i+4], 8, 8); err == nil {
+				b.WriteByte(byte(value))
+				i += 3
+				continue
+			}
+		}
+		b.WriteByte(field[i])
+	}
+
+	return b.String()
+}
+
+// findMount returns the mount containing path, i.e. the mount with the longest matching mount point.
+func findMount(path string) (mountEntry, error) {
+	absPath, err := filepath.Abs(path)
+	if err != nil {
+		return mountEntry{}, fmt.Errorf("failed to resolve path %s: %w", path, err)
+	}
+	if resolved, err := filepath.EvalSymlinks(absPath); err == nil {
+		absPath = resolved
+	}
+
+	mounts, err := readMountInfo()
+	if err != nil {
+		return mountEntry{}, err
+	}
+
+	var best mountEntry
+	found := false
+	for _, mount := range mounts {
+		rel, err := filepath.Rel(mount.mountPoint, absPath)
+		if err != nil || rel == ".." || strings.HasPrefix(rel, "../") {
+			continue
+		}
+		// Later entries shadow earlier ones mounted on the same point.
+		if !found || len(mount.mountPoint) >= len(best.mountPoint) {
+			best = mount
+			found = true
+		}
+	}
+
+	if !found {
+		return mountEntry{}, fmt.Errorf("no mount found for path %s", path)
+	}
+
+	return best, nil
+}
+
+// blockDeviceSysDir resolves the sysfs directory of the block device backing the filesystem containing path.
+// The device number of the path is tried first, falling back to the source device of its mount for
+// filesystems such as btrfs that report an anonymous device number.
+func blockDeviceSysDir(path string) (string, error) {
+	var stat syscall.Stat_t
+	if err := syscall.Stat(path, &stat); err != nil {
+		return "", fmt.Errorf("failed to stat %s: %w", path, err)
+	}
+
+	// Decoded like gnu_dev_major and gnu_dev_minor of glibc
+	dev := uint64(stat.Dev)
+	major := uint32((dev&0x00000000000fff00)>>8 | (dev&0xfffff00000000000)>>32)
+	minor := uint32(dev&0x00000000000000ff | (dev&0x00000ffffff00000)>>12)
+	if dir, err := filepath.EvalSymlinks(fmt.Sprintf("/sys/dev/block/%d:%d", major, minor)); err == nil {
+		return dir, nil
+	}
+
+	mount, err := findMount(path)
+	if err != nil {
+		return "", fmt.Errorf("%w: %w", ErrDeviceNotResolved, err)
+	}
+
+	source, err := filepath.EvalSymlinks(mount.source)
+	if err != nil || !strings.HasPrefix(source, "/dev/") {
+		return "", fmt.Errorf("%w: mount %s has source %s", ErrDeviceNotResolved, mount.mountPoint, mount.source)
+	}
+
+	dir, err := filepath.EvalSymlinks(filepath.Join("/sys/class/block", filepath.Base(source)))
+	if err != nil {
+		return "", fmt.Errorf("%w: %w", ErrDeviceNotResolved, err)
+	}
+
+	return dir, nil
+}
+
+// IsEncrypted determines whether the filesystem containing path is backed by a dm-crypt/LUKS device.
+//
+// The backing block device is resolved and its device mapper UUID is checked for a CRYPT- prefix
+// (CRYPT-LUKS1, CRYPT-LUKS2 or CRYPT-PLAIN). Stacked devices such as LVM on LUKS are handled by
+// walking the slaves of each device mapper device. If the device cannot be resolved at all, or some of
+// its slaves cannot be resolved and none of the others is encrypted, false is returned with an error
+// wrapping ErrDeviceNotResolved.
+func IsEncrypted(path string) (bool, error) {
+	dir, err := blockDeviceSysDir(path)
+	if err != nil {
+		return false, err
+	}
+
+	encrypted, err := isCryptDevice(dir, make(map[string]bool))
+	if err != nil {
+		return false, err
+	}
+	slog.Debug("Checked disk encryption", slog.String("path", path), slog.String("device", filepath.Base(dir)), slog.Bool("encrypted", encrypted))
+
+	return encrypted, nil
+}
+
+// isCryptDevice reports whether the block device at the sysfs directory, or any device below it, is a dm-crypt device.
+// Returns an error wrapping ErrDeviceNotResolved if no dm-crypt device was found but some slaves could not be resolved.
+func isCryptDevice(dir string, visited map[string]bool) (bool, error) {
+	if visited[dir] {
+		return false, nil
+	}
+	visited[dir] = true
+
+	if uuid, err := stringFromFile(filepath.Join(dir, "dm", "uuid")); err == nil && strings.HasPrefix(uuid, "CRYPT-") {
+		return true, nil
+	}
+
+	slaves, err := os.ReadDir(filepath.Join(dir, "slaves"))
+	if err != nil {
+		return false, nil
+	}
+
+	var unresolved error
+	for _, slave := range slaves {
+		slaveDir, err := filepath.EvalSymlinks(filepath.Join(dir, "slaves", slave.Name()))
+		if err != nil {
+			unresolved = fmt.Errorf("%w: slave %s of %s: %w", ErrDeviceNotResolved, slave.Name(), filepath.Base(dir), err)
+			continue
+		}
+		encrypted, err := isCryptDevice(slaveDir, visited)
+		if encrypted {
+			return true, nil
+		}
+		if err != nil {
+			unresolved = err
+		}
+	}
+
+	return false, unresolved
+}
+
+// blockDeviceParentDir resolves a block device name such as "sda1" or "/dev/nvme0n1p1" to the sysfs directory
+// of the whole disk, so partitions are mapped to their parent block device.
+func blockDeviceParentDir(device string) (string, error) {
+	name := filepath.Base(device)
+	if resolved, err := filepath.EvalSymlinks(device); err == nil && strings.HasPrefix(resolved, "/dev/") {
+		// Resolve links such as /dev/disk/by-uuid/... or /dev/mapper/... to the kernel name.
+		name = filepath.Base(resolved)
+	}
+
+	dir, err := filepath.EvalSymlinks(filepath.Join("/sys/class/block", name))
+	if err != nil {
+		return "", fmt.Errorf("failed to find block device %s: %w", device, err)
+	}
+
+	// Partitions are subdirectories of their disk and expose a partition attribute.
+	if _, err := os.Stat(filepath.Join(dir, "partition")); err == nil {
+		dir = filepath.Dir(dir)
+	}
+
+	return dir, nil
+}
+
+// IsRotational reports whether a block device is rotational (an HDD) rather than solid state.
+// Partitions are resolved to their parent block device.
+// Returns ErrRotationalUnsupported for devices that do not expose queue/rotational.
+func IsRotational(device string) (bool, error) {
+	if device == "" {
+		return false, fmt.Errorf("device name cannot be empty")
+	}
+
+	dir, err := blockDeviceParentDir(device)
+	if err != nil {
+		return false, err
+	}
+
+	rotational, err := intFromFile(filepath.Join(dir, "queue", "rotational"))
+	if err != nil {
+		if errors.Is(err, os.ErrNotExist) {
+			return false, fmt.Errorf("%w: %s", ErrRotationalUnsupported, filepath.Base(dir))
+		}
+		return false, fmt.Errorf("failed to get rotational attribute for %s: %w", device, err)
+	}
+
+	return rotational == 1, nil
+}
@@ -0,0 +1,187 @@
+package resourceutil
+
+import (
+	"bufio"
+	"fmt"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+)
+
+// SwapType is the kind of backing store of a swap area.
+type SwapType string
+
+const (
+	SwapTypePartition SwapType = "partition"
+	SwapTypeFile      SwapType = "file"
+	SwapTypeZram      SwapType = "zram"
+)
+
+// SwapDevice represents a single active swap area from /proc/swaps.
+// Fields:
+//   - Name (string): The path of the swap partition or file.
+//   - Type (SwapType): The kind of swap area, zram devices are reported separately from other partitions.
+//   - SizeGB (float64): The size of the swap area in gigabytes.
+//   - UsedGB (float64): The amount of the swap area in use in gigabytes.
+//   - Priority (int): The swap priority, higher priority areas are used first.
+type SwapDevice struct {
+	Name     string
+	Type     SwapType
+	SizeGB   float64
+	UsedGB   float64
+	Priority int
+}
+
+// SwapTotals represents the combined size and usage of a group of swap areas.
+// Fields:
+//   - Count (int): The number of swap areas in the group.
+//   - TotalGB (float64): The combined size in gigabytes.
+//   - UsedGB (float64): The combined amount in use in gigabytes.
+//   - UsedPercent (float64): The percentage of the combined size in use, 0 if the group is empty.
+type SwapTotals struct {
+	Count       int
+	TotalGB     float64
+	UsedGB      float64
+	UsedPercent float64
+}
+
+// SwapSummary represents the swap usage totalled separately by swap type.
+type SwapSummary struct {
+	Partition SwapTotals
+	File      SwapTotals
+	Zram      SwapTotals
+}
+
+// GetSwapDevices retrieves the active swap areas from /proc/swaps.
+// Returns an empty slice when swap is off.
+func GetSwapDevices() ([]SwapDevice, error) {
+	file, err := os.Open("/proc/swaps")
+	if err != nil {
+		slog.Error("Failed to read swap info", slog.String("path", "/proc/swaps"), slog.Any("error", err))
+		return nil, err
+	}
+	defer file.Close()
+
+	devices := []SwapDevice{}
+	scanner := bufio.NewScanner(file)
+
+	// Skip the header line
+	scanner.Scan()
+
+	for scanner.Scan() {
+		// Fields: Filename Type Size Used Priority, sizes are in kB
+		fields := strings.Fields(scanner.Text())
+		if len(fields) != 5 {
+			return nil, fmt.Errorf("unexpected number of fields in /proc/swaps, line: %s", scanner.Text())
+		}
+
+		sizekB, err := strconv.ParseUint(fields[2], 10, 64)
+		if err != nil {
+			return nil, fmt.Errorf("failed to parse swap size for %s: %w", fields[0], err)
+		}
+		usedkB, err := strconv.ParseUint(fields[3], 10, 64)
+		if err != nil {
+			return nil, fmt.Errorf("failed to parse swap usage for %s: %w", fields[0], err)
+		}
+		priority, err := strconv.Atoi(fields[4])
+		if err != nil {
+			return nil, fmt.Errorf("failed to parse swap priority for %s: %w", fields[0], err)
+		}
+
+		name := unescapeMountField(fields[0])
+		swapType := SwapType(fields[1])
+		if swapType == SwapTypePartition && strings.HasPrefix(filepath.Base(name), "zram") {
+			swapType = SwapTypeZram
+		}
+
+		devices = append(devices, SwapDevice{
+			Name:     name,
+			Type:     swapType,
+			SizeGB:   float64(sizekB) / (1024 * 1024),
+			UsedGB:   float64(usedkB) / (1024 * 1024),
+			Priority: priority,
+		})
+	}
+
+	if err := scanner.Err(); err != nil {
+		slog.Error("Failed to scan /proc/swaps", slog.Any("error", err))
+		return nil, err
+	}
+
+	slog.Debug("Got swap devices", slog.Any("swap_devices", devices))
+
+	return devices, nil
+}
+
+// GetSwapSummary retrieves the swap usage totalled separately for partitions, files and zram devices.
+// All totals are zero when swap is off.
+func GetSwapSummary() (SwapSummary, error) {
+	devices, err := GetSwapDevices()
+	if err != nil {
+		return SwapSummary{}, err
+	}
+
+	var summary SwapSummary
+	for _, device := range devices {
+		var totals *SwapTotals
+		switch device.Type {
+		case SwapTypeZram:
+			totals = &summary.Zram
+		case SwapTypeFile:
+			totals = &summary.File
+		default:
+			totals = &summary.Partition
+		}
+		totals.Count++
+		totals.TotalGB += device.SizeGB
+		totals.UsedGB += device.UsedGB
+	}
+
+	for _, totals := range []*SwapTotals{&summary.Partition, &summary.File, &summary.Zram} {
+		if totals.TotalGB > 0 {
+			totals.UsedPercent = 100 * totals.UsedGB / totals.TotalGB
+		}
+	}
+
+	return summary, nil
+}
+
+// SwapUsage represents the system-wide swap usage from /proc/meminfo.
+// Fields:
+//   - TotalGB (float64): The total swap size in gigabytes.
+//   - FreeGB (float64): The unused swap in gigabytes.
+//   - UsedGB (float64): The amount of swap in use in gigabytes.
+//   - UsedPercent (float64): The percentage of swap in use, 0 when swap is off.
+type SwapUsage struct {
+	TotalGB     float64
+	FreeGB      float64
+	UsedGB      float64
+	UsedPercent float64
+}
+
+// GetSwapUsage retrieves the system-wide swap usage from SwapTotal and SwapFree in /proc/meminfo.
+// All values are zero when swap is off. Use GetSwapDevices for the usage of each swap area.
+func GetSwapUsage() (SwapUsage, error) {
+	info, err := GetMemInfo()
+	if err != nil {
+		return SwapUsage{}, err
+	}
+
+	const bytesPerGB = 1024 * 1024 * 1024
+	swapTotal := float64(info.SwapTotal) / bytesPerGB
+	swapFree := float64(info.SwapFree) / bytesPerGB
+
+	usage := SwapUsage{
+		TotalGB: swapTotal,
+		FreeGB:  swapFree,
+		UsedGB:  swapTotal - swapFree,
+	}
+	if swapTotal > 0 {
+		usage.UsedPercent = 100 * usage.UsedGB / swapTotal
+	}
+	slog.Debug("Got swap usage", slog.Any("swap_usage", usage))
+
+	return usage, nil
+}
@@ -0,0 +1,196 @@
+package resourceutil
+
+import (
+	"errors"
+	"fmt"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"time"
+)
+
+// thermalClassDir is the sysfs directory where the kernel exposes thermal zones.
+const thermalClassDir = "/sys/class/thermal"
+
+// cpuThermalZoneTypes are the thermal zone types that measure the CPU, in order of preference.
+// The ACPI thermal zone is a last resort as it often measures the motherboard rather than the CPU.
+var cpuThermalZoneTypes = []string{
+	"x86_pkg_temp",
+	"cpu-thermal",
+	"cpu_thermal",
+	"cpu0-thermal",
+	"soc_thermal",
+	"soc-thermal",
+	"acpitz",
+}
+
+// ErrNoCPUThermalZone is returned when no thermal zone measuring the CPU can be found.
+var ErrNoCPUThermalZone = errors.New("no CPU thermal zone found")
+
+// thermalZone represents a thermal zone in sysfs.
+type thermalZone struct {
+	zoneType string
+	dir      string
+}
+
+// listThermalZones returns the thermal zones exposed by the kernel.
+func listThermalZones() ([]thermalZone, error) {
+	entries, err := os.ReadDir(thermalClassDir)
+	if err != nil {
+		if errors.Is(err, os.ErrNotExist) {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("failed to list thermal zones in %s: %w", thermalClassDir, err)
+	}
+
+	var zones []thermalZone
+	for _, entry := range entries {
+		if !strings.HasPrefix(entry.Name(), "thermal_zone") {
+			continue
+		}
+		dir := filepath.Join(thermalClassDir, entry.Name())
+		zoneType, err := stringFromFile(filepath.Join(dir, "type"))
+		if err != nil {
+			continue
+		}
+		zones = append(zones, thermalZone{zoneType: zoneType, dir: dir})
+	}
+
+	return zones, nil
+}
+
+// readThermalZoneTemp reads the temperature of a thermal zone in degrees Celsius.
+func readThermalZoneTemp(zone thermalZone) (float64, error) {
+	milliCelsius, err := intFromFile(filepath.Join(zone.dir, "temp"))
+	if err != nil {
+		return 0, fmt.Errorf("failed to get temperature of thermal zone %s: %w", zone.zoneType, err)
+	}
+
+	return float64(milliCelsius) / 1000, nil
+}
+
+// GetCPUTemperature retrieves the CPU temperature in degrees Celsius from the most relevant thermal zone,
+// such as x86_pkg_temp on Intel or cpu-thermal on ARM boards.
+// Returns ErrNoCPUThermalZone if no thermal zone measuring the CPU exists.
+func GetCPUTemperature() (float64, error) {
+	zones, err := listThermalZones()
+	if err != nil {
+		return 0, err
+	}
+
+	for _, zoneType := range cpuThermalZoneTypes {
+		for _, zone := range zones {
+			if zone.zoneType != zoneType {
+				continue
+			}
+
+			temp, err := readThermalZoneTemp(zone)
+			if err != nil {
+				// Some zones fail to read while their sensor is powered down, try the next one
+				slog.Warn("Failed to read CPU thermal zone", slog.String("zone", zone.dir), slog.Any("error", err))
+				continue
+			}
+
+			slog.Debug("Got CPU temperature", slog.String("zone_type", zone.zoneType), slog.Float64("temperature_celsius", temp))
+			return temp, nil
+		}
+	}
+
+	return 0, ErrNoCPUThermalZone
+}
+
+// ErrThrottleCountUnsupported is returned when the CPUs do not expose thermal throttle counters,
+// which are only provided by the Intel therm_throt driver.
+var ErrThrottleCountUnsupported = errors.New("thermal throttle counters not available")
+
+// ThrottleCount represents the number of thermal throttling events of a CPU.
+// Fields:
+//   - CPU (int): The CPU number.
+//   - Core (uint64): The number of times the core was throttled.
+//   - Package (uint64): The number of times the package containing the core was throttled.
+type ThrottleCount struct {
+	CPU     int
+	Core    uint64
+	Package uint64
+}
+
+// GetThermalThrottleCounts retrieves the number of thermal throttling events of each online CPU since boot,
+// ordered by CPU number. Cores that go offline while reading are omitted. Returns ErrThrottleCountUnsupported if no CPU exposes thermal_throttle.
+func GetThermalThrottleCounts() ([]ThrottleCount, error) {
+	cpus, err := onlineCPUs()
+	if err != nil {
+		return nil, err
+	}
+	slices.Sort(cpus)
+
+	var counts []ThrottleCount
+	for _, cpu := range cpus {
+		dir := filepath.Join(cpuSysDir, fmt.Sprintf("cpu%d", cpu), "thermal_throttle")
+
+		// Cores going offline fail with EBUSY or ENODEV and are skipped like cores without counters
+		core, err := uint64FromFile(filepath.Join(dir, "core_throttle_count"))
+		if err != nil {
+			if !errors.Is(err, os.ErrNotExist) {
+				slog.Debug("Skipping CPU without readable core throttle count", slog.Int("cpu", cpu), slog.Any("error", err))
+			}
+			continue
+		}
+		pkg, err := uint64FromFile(filepath.Join(dir, "package_throttle_count"))
+		if err != nil && !errors.Is(err, os.ErrNotExist) {
+			slog.Debug("Skipping CPU without readable package throttle count", slog.Int("cpu", cpu), slog.Any("error", err))
+			continue
+		}
+
+		counts = append(counts, ThrottleCount{CPU: cpu, Core: core, Package: pkg})
+	}
+
+	if len(counts) == 0 {
+		return nil, ErrThrottleCountUnsupported
+	}
+
+	slog.Debug("Got thermal throttle counts", slog.Any("throttle_counts", counts))
+
+	return counts, nil
+}
+
+// GetThermalThrottleEvents measures the number of thermal throttling events of each CPU over the given interval.
+// This call blocks for the duration of the interval. CPUs that go offline during the interval are omitted.
+func GetThermalThrottleEvents(interval time.Duration) ([]ThrottleCount, error) {
+	if interval <= 0 {
+		return nil, fmt.Errorf("interval must be positive, got %s", interval)
+	}
+
+	before, err := GetThermalThrottleCounts()
+	if err != nil {
+		return nil, err
+	}
+
+	time.Sleep(interval)
+
+	after, err := GetThermalThrottleCounts()
+	if err != nil {
+		return nil, err
+	}
+
+	previous := make(map[int]ThrottleCount, len(before))
+	for _, count := range before {
+		previous[count.CPU] = count
+	}
+
+	events := make([]ThrottleCount, 0, len(after))
+	for _, count := range after {
+		prev, ok := previous[count.CPU]
+		if !ok {
+			continue
+		}
+		events = append(events, ThrottleCount{
+			CPU:     count.CPU,
+			Core:    count.Core - min(prev.Core, count.Core),
+			Package: count.Package - min(prev.Package, count.Package),
+		})
+	}
+
+	return events, nil
+}
@@ -0,0 +1,157 @@
+package resourceutil
+
+import (
+	"io/fs"
+	"log/slog"
+	"path/filepath"
+	"syscall"
+)
+
+// TmpfsUsage represents the usage of a tmpfs or ramfs mount and an estimate of how much of it resides in RAM.
+// Fields:
+//   - MountPoint (string): The path the filesystem is mounted on.
+//   - FSType (string): The filesystem type, "tmpfs" or "ramfs".
+//   - TotalGB (float64): The size limit of the mount in gigabytes, 0 for ramfs which has no limit.
+//   - UsedGB (float64): The amount of data stored in the mount in gigabytes.
+//   - UsedPercent (float64): The percentage of the size limit in use, 0 for ramfs.
+//   - ResidentGB (float64): The estimated amount of the data resident in RAM in gigabytes.
+//   - SwappedGB (float64): The estimated amount of the data swapped out in gigabytes.
+type TmpfsUsage struct {
+	MountPoint  string
+	FSType      string
+	TotalGB     float64
+	UsedGB      float64
+	UsedPercent float64
+	ResidentGB  float64
+	SwappedGB   float64
+}
+
+// GetTmpfsUsage retrieves the usage of every tmpfs and ramfs mount, with an estimate of the resident and swapped
+// portions. These filesystems store their data in RAM although GetDiskUsage reports them like disks.
+// Returns an empty slice when neither is mounted.
+//
+// ramfs does not track its usage, so it is measured by walking the files of the mount, which is slow for
+// mounts holding many files. ramfs is never swapped out, so all of its data is resident.
+//
+// The kernel does not report swap usage per tmpfs mount, so the split is estimated: Shmem from
+// /proc/meminfo is the tmpfs and shared memory resident in RAM, and any tmpfs data beyond it is
+// assumed to be swapped out, distributed over the mounts in proportion to their usage. Shmem also
+// counts SysV and POSIX shared memory and shared anonymous mappings, so the resident portion is
+// overestimated on systems that make heavy use of those. Pages in SwapCached are both in swap and
+// in RAM and are counted as resident.
+func GetTmpfsUsage() ([]TmpfsUsage, error) {
+	mounts, err := readMountInfo()
+	if err != nil {
+		return nil, err
+	}
+
+	// A mount hides earlier mounts on the same mount point, and bind mounts of the same tmpfs
+	// share a device number, so both are skipped to avoid counting a filesystem twice.
+	visible := make(map[string]mountEntry)
+	for _, mount := range mounts {
+		visible[mount.mountPoint] = mount
+	}
+
+	usages := []TmpfsUsage{}
+	seen := make(map[string]bool)
+	totalUsedGB := 0.0
+	for _, mount := range mounts {
+		if (mount.fsType != "tmpfs" && mount.fsType != "ramfs") || visible[mount.mountPoint] != mount || seen[mount.majorMinor] {
+			continue
+		}
+		seen[mount.majorMinor] = true
+
+		if mount.fsType == "ramfs" {
+			usedGB, err := ramfsUsedGB(mount.mountPoint)
+			if err != nil {
+				slog.Warn("Skipping ramfs mount", slog.String("mount_point", mount.mountPoint), slog.Any("error", err))
+				continue
+			}
+			usages = append(usages, TmpfsUsage{
+				MountPoint: mount.mountPoint,
+				FSType:     mount.fsType,
+				UsedGB:     usedGB,
+				ResidentGB: usedGB,
+			})
+			continue
+		}
+
+		storage, err := GetDiskUsage(mount.mountPoint)
+		if err != nil {
+			slog.Warn("Skipping tmpfs mount", slog.String("mount_point", mount.mountPoint), slog.Any("error", err))
+			continue
+		}
+
+		usages = append(usages, TmpfsUsage{
+			MountPoint:  mount.mountPoint,
+			FSType:      mount.fsType,
+			TotalGB:     storage.TotalGB,
+			UsedGB:      storage.UsedGB,
+			UsedPercent: storage.UsedPercent,
+		})
+		totalUsedGB += storage.UsedGB
+	}
+
+	if totalUsedGB == 0 {
+		return usages, nil
+	}
+
+	info, err := GetMemInfo()
+	if err != nil {
+		return nil, err
+	}
+	const bytesPerGB = 1024 * 1024 * 1024
+	shmem := float64(info.Shmem) / bytesPerGB
+
+	residentRatio := min(shmem/totalUsedGB, 1)
+	for i := range usages {
+		if usages[i].FSType != "tmpfs" {
+			continue
+		}
+		usages[i].ResidentGB = usages[i].UsedGB * residentRatio
+		usages[i].SwappedGB = usages[i].UsedGB - usages[i].ResidentGB
+	}
+
+	slog.Debug("Got tmpfs usage", slog.Any("tmpfs_usage", usages))
+
+	return usages, nil
+}
+
+// ramfsUsedGB sums the space allocated to the files below a ramfs mount point in gigabytes,
+// without descending into other filesystems mounted below it.
+func ramfsUsedGB(mountPoint string) (float64, error) {
+	var root syscall.Stat_t
+	if err := syscall.Stat(mountPoint, &root); err != nil {
+		return 0, err
+	}
+
+	var used uint64
+	err := filepath.WalkDir(mountPoint, func(path string, entry fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		info, err := entry.Info()
+		if err != nil {
+			return err
+		}
+		stat, ok := info.Sys().(*syscall.Stat_t)
+		if !ok {
+			return nil
+		}
+		if stat.Dev != root.Dev {
+			if entry.IsDir() {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+		// Blocks are in 512 byte units regardless of the block size of the filesystem
+		used += uint64(stat.Blocks) * 512
+		return nil
+	})
+	if err != nil {
+		return 0, err
+	}
+
+	const bytesPerGB = 1024 * 1024 * 1024
+	return float64(used) / bytesPerGB, nil
+}
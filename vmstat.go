@@ -0,0 +1,156 @@
+package resourceutil
+
+import (
+	"fmt"
+	"log/slog"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// VMStat represents the paging counters of /proc/vmstat, cumulative since boot.
+// Fields:
+//   - PageFaults (uint64): The number of page faults, minor and major (pgfault).
+//   - MajorFaults (uint64): The number of page faults that required reading from disk (pgmajfault).
+//   - SwapIns (uint64): The number of pages read in from swap (pswpin).
+//   - SwapOuts (uint64): The number of pages written out to swap (pswpout).
+//   - PagesScanned (uint64): The number of pages scanned for reclaim by kswapd, direct reclaim and khugepaged (pgscan).
+//   - PagesStolen (uint64): The number of pages reclaimed out of the scanned ones (pgsteal).
+type VMStat struct {
+	PageFaults   uint64
+	MajorFaults  uint64
+	SwapIns      uint64
+	SwapOuts     uint64
+	PagesScanned uint64
+	PagesStolen  uint64
+}
+
+// VMStatRates represents the paging activity of the system over an interval.
+// Fields:
+//   - PageFaultsPerSec (float64): The number of page faults per second.
+//   - MajorFaultsPerSec (float64): The number of major page faults per second.
+//   - SwapInsPerSec (float64): The number of pages read in from swap per second.
+//   - SwapOutsPerSec (float64): The number of pages written out to swap per second.
+//   - PagesScannedPerSec (float64): The number of pages scanned for reclaim per second.
+//   - PagesStolenPerSec (float64): The number of pages reclaimed per second.
+//
+// Sustained major faults and swap-ins show the working set no longer fits in memory.
+// A low ratio of stolen to scanned pages means reclaim is working hard for little gain.
+type VMStatRates struct {
+	PageFaultsPerSec   float64
+	MajorFaultsPerSec  float64
+	SwapInsPerSec      float64
+	SwapOutsPerSec     float64
+	PagesScannedPerSec float64
+	PagesStolenPerSec  float64
+}
+
+// GetVMStat retrieves the paging counters from /proc/vmstat.
+func GetVMStat() (VMStat, error) {
+	counters, err := readVMStat()
+	if err != nil {
+		return VMStat{}, err
+	}
+
+	stat := VMStat{
+		PageFaults:   counters["pgfault"],
+		MajorFaults:  counters["pgmajfault"],
+		SwapIns:      counters["pswpin"],
+		SwapOuts:     counters["pswpout"],
+		PagesScanned: reclaimCounter(counters, "pgscan"),
+		PagesStolen:  reclaimCounter(counters, "pgsteal"),
+	}
+	slog.Debug("Got vmstat counters", slog.Any("vmstat", stat))
+
+	return stat, nil
+}
+
+// GetVMStatRates measures the paging rates from /proc/vmstat over the given interval.
+// This call blocks for the duration of the interval.
+func GetVMStatRates(interval time.Duration) (VMStatRates, error) {
+	if interval <= 0 {
+		return VMStatRates{}, fmt.Errorf("interval must be positive, got %s", interval)
+	}
+
+	before, err := GetVMStat()
+	if err != nil {
+		return VMStatRates{}, err
+	}
+	start := time.Now()
+
+	time.Sleep(interval)
+
+	after, err := GetVMStat()
+	if err != nil {
+		return VMStatRates{}, err
+	}
+	seconds := time.Since(start).Seconds()
+
+	rate := func(before, after uint64) float64 {
+		if after < before {
+			return 0
+		}
+		return float64(after-before) / seconds
+	}
+
+	rates := VMStatRates{
+		PageFaultsPerSec:   rate(before.PageFaults, after.PageFaults),
+		MajorFaultsPerSec:  rate(before.MajorFaults, after.MajorFaults),
+		SwapInsPerSec:      rate(before.SwapIns, after.SwapIns),
+		SwapOutsPerSec:     rate(before.SwapOuts, after.SwapOuts),
+		PagesScannedPerSec: rate(before.PagesScanned, after.PagesScanned),
+		PagesStolenPerSec:  rate(before.PagesStolen, after.PagesStolen),
+	}
+	slog.Debug("Got vmstat rates", slog.Any("vmstat_rates", rates))
+
+	return rates, nil
+}
+
+// reclaimCounter totals a page reclaim counter such as pgscan or pgsteal. Linux 5.8 and later split it by
+// page type into _anon and _file, older kernels only split it by reclaimer and, before 4.8, by memory zone.
+func reclaimCounter(counters map[string]uint64, name string) uint64 {
+	anon, hasAnon := counters[name+"_anon"]
+	file, hasFile := counters[name+"_file"]
+	if hasAnon && hasFile {
+		return anon + file
+	}
+
+	var total uint64
+	for key, value := range counters {
+		reclaimer, ok := strings.CutPrefix(key, name+"_")
+		if !ok || reclaimer == "direct_throttle" {
+			// pgscan_direct_throttle counts throttled tasks, not pages
+			continue
+		}
+		if strings.HasPrefix(reclaimer, "kswapd") || strings.HasPrefix(reclaimer, "direct") || reclaimer == "khugepaged" {
+			total += value
+		}
+	}
+
+	return total
+}
+
+// readVMStat reads the "name value" counters of /proc/vmstat.
+func readVMStat() (map[string]uint64, error) {
+	data, err := os.ReadFile("/proc/vmstat")
+	if err != nil {
+		slog.Error("Failed to read virtual memory statistics", slog.String("path", "/proc/vmstat"), slog.Any("error", err))
+		return nil, err
+	}
+
+	counters := make(map[string]uint64)
+	for _, line := range strings.Split(string(data), "\n") {
+		key, valueStr, ok := strings.Cut(line, " ")
+		if !ok {
+			continue
+		}
+		value, err := strconv.ParseUint(valueStr, 10, 64)
+		if err != nil {
+			return nil, fmt.Errorf("failed to parse %s in /proc/vmstat: %w", key, err)
+		}
+		counters[key] = value
+	}
+
+	return counters, nil
+}
@@ -0,0 +1,191 @@
+package resourceutil
+
+import (
+	"errors"
+	"fmt"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+)
+
+const (
+	// zswapParamsDir is the sysfs directory of the zswap module parameters.
+	zswapParamsDir = "/sys/module/zswap/parameters"
+	// zswapDebugDir is the debugfs directory of the zswap statistics, only readable by root.
+	zswapDebugDir = "/sys/kernel/debug/zswap"
+)
+
+// ErrZswapUnsupported is returned when the kernel is built without zswap.
+var ErrZswapUnsupported = errors.New("zswap not supported by the kernel")
+
+// ZramDevice represents the usage of a zram compressed RAM block device.
+// Fields:
+//   - Name (string): The name of the device, e.g. "zram0".
+//   - Algorithm (string): The selected compression algorithm.
+//   - DiskSizeGB (float64): The uncompressed capacity of the device in gigabytes, 0 if it is not initialized.
+//   - OrigDataGB (float64): The uncompressed size of the data stored in the device in gigabytes.
+//   - ComprDataGB (float64): The compressed size of the data stored in the device in gigabytes.
+//   - MemUsedGB (float64): The RAM used to store the data, including allocator overhead, in gigabytes.
+//   - MemUsedMaxGB (float64): The highest RAM used since the device was initialized in gigabytes.
+//   - MemLimitGB (float64): The maximum RAM the device may use in gigabytes, 0 if unlimited.
+//   - CompressionRatio (float64): OrigDataGB divided by ComprDataGB, 0 while the device is empty.
+type ZramDevice struct {
+	Name             string
+	Algorithm        string
+	DiskSizeGB       float64
+	OrigDataGB       float64
+	ComprDataGB      float64
+	MemUsedGB        float64
+	MemUsedMaxGB     float64
+	MemLimitGB       float64
+	CompressionRatio float64
+}
+
+// GetZramDevices retrieves the usage of each zram device from /sys/block/zram*/mm_stat.
+// Returns an empty slice when there are no zram devices.
+func GetZramDevices() ([]ZramDevice, error) {
+	dirs, err := filepath.Glob("/sys/block/zram*")
+	if err != nil {
+		return nil, fmt.Errorf("failed to list zram devices: %w", err)
+	}
+
+	const bytesPerGB = 1024 * 1024 * 1024
+	devices := make([]ZramDevice, 0, len(dirs))
+	for _, dir := range dirs {
+		name := filepath.Base(dir)
+
+		diskSize, err := uint64FromFile(filepath.Join(dir, "disksize"))
+		if err != nil {
+			return nil, fmt.Errorf("failed to get disk size of %s: %w", name, err)
+		}
+
+		// Fields: orig_data_size compr_data_size mem_used_total mem_limit mem_used_max same_pages
+		// pages_compacted huge_pages [huge_pages_since], the first five are in bytes
+		mmStat, err := stringFromFile(filepath.Join(dir, "mm_stat"))
+		if err != nil {
+			return nil, fmt.Errorf("failed to get memory statistics of %s: %w", name, err)
+		}
+		fields := strings.Fields(mmStat)
+		if len(fields) < 5 {
+			return nil, fmt.Errorf("unexpected number of fields in mm_stat of %s: %s", name, mmStat)
+		}
+		var stats [5]uint64
+		for i := range stats {
+			if stats[i], err = strconv.ParseUint(fields[i], 10, 64); err != nil {
+				return nil, fmt.Errorf("failed to parse mm_stat field %d of %s: %w", i, name, err)
+			}
+		}
+
+		algorithm, err := stringFromFile(filepath.Join(dir, "comp_algorithm"))
+		if err != nil {
+			return nil, fmt.Errorf("failed to get compression algorithm of %s: %w", name, err)
+		}
+
+		if selected, ok := selectedOption(algorithm); ok {
+			algorithm = selected
+		}
+
+		device := ZramDevice{
+			Name:         name,
+			Algorithm:    algorithm,
+			DiskSizeGB:   float64(diskSize) / bytesPerGB,
+			OrigDataGB:   float64(stats[0]) / bytesPerGB,
+			ComprDataGB:  float64(stats[1]) / bytesPerGB,
+			MemUsedGB:    float64(stats[2]) / bytesPerGB,
+			MemLimitGB:   float64(stats[3]) / bytesPerGB,
+			MemUsedMaxGB: float64(stats[4]) / bytesPerGB,
+		}
+		if stats[1] > 0 {
+			device.CompressionRatio = float64(stats[0]) / float64(stats[1])
+		}
+		devices = append(devices, device)
+	}
+
+	slog.Debug("Got zram devices", slog.Any("zram_devices", devices))
+
+	return devices, nil
+}
+
+// ZswapUsage represents the usage of zswap, the compressed cache for pages being swapped out.
+// Fields:
+//   - Enabled (bool): Whether zswap is enabled.
+//   - Compressor (string): The compression algorithm.
+//   - MaxPoolPercent (int): The maximum percentage of RAM the compressed pool may use.
+//   - PoolGB (float64): The RAM used by the compressed pool in gigabytes.
+//   - StoredGB (float64): The uncompressed size of the pages stored in the pool in gigabytes.
+//   - CompressionRatio (float64): StoredGB divided by PoolGB, 0 while the pool is empty.
+//
+// The pool sizes come from Zswap and Zswapped in /proc/meminfo on Linux 5.19 and later. Older kernels only
+// expose them in debugfs, which requires root, so they are zero if debugfs cannot be read.
+type ZswapUsage struct {
+	Enabled          bool
+	Compressor       string
+	MaxPoolPercent   int
+	PoolGB           float64
+	StoredGB         float64
+	CompressionRatio float64
+}
+
+// GetZswapUsage retrieves the zswap settings from /sys/module/zswap/parameters and the pool usage from
+// /proc/meminfo, falling back to /sys/kernel/debug/zswap. Returns ErrZswapUnsupported if the kernel has no zswap.
+func GetZswapUsage() (ZswapUsage, error) {
+	enabled, err := stringFromFile(filepath.Join(zswapParamsDir, "enabled"))
+	if errors.Is(err, os.ErrNotExist) {
+		return ZswapUsage{}, ErrZswapUnsupported
+	}
+	if err != nil {
+		return ZswapUsage{}, fmt.Errorf("failed to get zswap state: %w", err)
+	}
+	compressor, err := stringFromFile(filepath.Join(zswapParamsDir, "compressor"))
+	if err != nil {
+		return ZswapUsage{}, fmt.Errorf("failed to get zswap compressor: %w", err)
+	}
+	maxPoolPercent, err := intFromFile(filepath.Join(zswapParamsDir, "max_pool_percent"))
+	if err != nil {
+		return ZswapUsage{}, fmt.Errorf("failed to get zswap max pool percent: %w", err)
+	}
+
+	info, err := GetMemInfo()
+	if err != nil {
+		return ZswapUsage{}, err
+	}
+	pool, stored := info.Zswap, info.Zswapped
+	if pool == 0 {
+		// Older kernels do not report zswap in /proc/meminfo
+		pool, stored = readZswapDebugStats()
+	}
+
+	const bytesPerGB = 1024 * 1024 * 1024
+	usage := ZswapUsage{
+		Enabled:        enabled == "Y",
+		Compressor:     compressor,
+		MaxPoolPercent: maxPoolPercent,
+		PoolGB:         float64(pool) / bytesPerGB,
+		StoredGB:       float64(stored) / bytesPerGB,
+	}
+	if pool > 0 {
+		usage.CompressionRatio = float64(stored) / float64(pool)
+	}
+	slog.Debug("Got zswap usage", slog.Any("zswap_usage", usage))
+
+	return usage, nil
+}
+
+// readZswapDebugStats reads the pool size and the uncompressed size of the stored pages in bytes from debugfs.
+// Returns zeros if debugfs is not mounted or not readable.
+func readZswapDebugStats() (pool, stored uint64) {
+	pool, err := uint64FromFile(filepath.Join(zswapDebugDir, "pool_total_size"))
+	if err != nil {
+		slog.Debug("Unable to read zswap pool size from debugfs", slog.Any("error", err))
+		return 0, 0
+	}
+	storedPages, err := uint64FromFile(filepath.Join(zswapDebugDir, "stored_pages"))
+	if err != nil {
+		slog.Debug("Unable to read zswap stored pages from debugfs", slog.Any("error", err))
+		return 0, 0
+	}
+
+	return pool, storedPages * uint64(os.Getpagesize())
+}